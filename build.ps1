@@ -1 +1,2 @@
-go build -ldflags="-w -s" -o .\bin\avito-estate-parser.exe github.com/csr-ugra/avito-estate-parser
\ No newline at end of file
+$version = git describe --tags --always --dirty
+go build -ldflags="-w -s -X github.com/csr-ugra/avito-estate-parser/internal.Version=$version" -o .\bin\avito-estate-parser.exe github.com/csr-ugra/avito-estate-parser
\ No newline at end of file
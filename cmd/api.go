@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"github.com/csr-ugra/avito-estate-parser/internal/api"
+	"github.com/csr-ugra/avito-estate-parser/internal/log"
+	"github.com/uptrace/bun"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Api serves json api over parsing results until interrupted
+func Api(ctx context.Context, connection bun.IDB, args []string) error {
+	var addr string
+	fs := flag.NewFlagSet("api", flag.ExitOnError)
+	fs.StringVar(&addr, "addr", ":8080", "address to listen on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	return listenAndServe(ctx, addr, api.NewHandler(connection))
+}
+
+// serves handler until context is done, then shuts server down gracefully
+func listenAndServe(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.GetLogger().WithField("Addr", addr).Info("http server listening on {Addr}")
+	err := server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
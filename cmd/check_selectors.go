@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal"
+	"github.com/csr-ugra/avito-estate-parser/internal/log"
+	"github.com/csr-ugra/avito-estate-parser/internal/parser"
+	"github.com/csr-ugra/avito-estate-parser/internal/selector"
+	"github.com/csr-ugra/avito-estate-parser/internal/util"
+	"github.com/uptrace/bun"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// daily rent widget is shown on region wide daily rent category pages
+const defaultDailyRentWidgetUrl = "https://www.avito.ru/hanty-mansiyskiy_ao/doma_dachi_kottedzhi/sdam/posutochno-ASgBAgICAkSUA9IQoAjKVQ"
+
+// CheckSelectors opens sample page of every page type and prints which selectors still match,
+// fails when required selector is missing so it can gate scheduled run
+func CheckSelectors(ctx context.Context, connection bun.IDB, config *util.Config, args []string) error {
+	var opts parser.Options
+	var taskId int
+	var selectors, baseEstateUrl, dailyRentUrl, date string
+	fs := flag.NewFlagSet("check-selectors", flag.ExitOnError)
+	fs.IntVar(&taskId, "task-id", 0, "task to take estate list and base estate page urls from, default: first enabled task")
+	fs.StringVar(&baseEstateUrl, "base-estate-url", "", "url of page with base estate widget, default: estate page of task location")
+	fs.StringVar(&dailyRentUrl, "daily-rent-url", defaultDailyRentWidgetUrl, "url of page with daily rent widget")
+	fs.StringVar(&date, "date", "", "date to check calendar day selectors with, default: tomorrows date")
+	fs.StringVar(&selectors, "selectors", "", "yaml or json file to load selectors from, 'db' to load them from db, default: built-in selectors")
+	fs.DurationVar(&opts.NavigationTimeout, "navigation-timeout", time.Minute, "max time to wait for page to load, 0 disables limit")
+	fs.StringVar(&opts.FixtureDir, "fixtures", "", "check saved html pages from directory with fixtures.json instead of live pages")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	day := time.Now().Add(24 * time.Hour)
+	if date != "" {
+		var err error
+		if day, err = time.Parse(time.DateOnly, date); err != nil {
+			return fmt.Errorf("invalid date: %v", err)
+		}
+	}
+
+	if err := internal.LoadSelectors(ctx, connection, selectors); err != nil {
+		return err
+	}
+	log.GetLogger().WithField("SelectorVersion", selector.Version()).Info("using selectors version {SelectorVersion}")
+
+	taskUrl, taskBaseEstateUrl, err := internal.SampleUrls(ctx, connection, taskId)
+	if err != nil {
+		return err
+	}
+	if baseEstateUrl == "" {
+		baseEstateUrl = taskBaseEstateUrl
+	}
+
+	checks, err := parser.CheckSelectors(config, opts, map[parser.PageType]string{
+		parser.PageEstateList:       taskUrl,
+		parser.PageBaseEstateWidget: baseEstateUrl,
+		parser.PageDailyRentWidget:  dailyRentUrl,
+	}, day)
+	if err != nil {
+		return err
+	}
+
+	missingCount := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PAGE\tSELECTOR\tREQUIRED\tMATCHES\tRESULT")
+	for _, c := range checks {
+		result := "pass"
+		switch {
+		case !c.Passed() && c.Required:
+			result = "FAIL"
+			missingCount++
+		case !c.Passed():
+			result = "missing"
+		}
+
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%t\t%d\t%s\n", c.Page, c.Name, c.Required, c.MatchCount, result)
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+
+	if missingCount > 0 {
+		return fmt.Errorf("%d required selectors not found, selectors version %s", missingCount, selector.Version())
+	}
+
+	return nil
+}
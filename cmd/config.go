@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/util"
+	"os"
+	"text/tabwriter"
+)
+
+// Config shows configuration: config print|help
+func Config(config *util.Config, args []string) error {
+	action, _, err := splitAction("config", args, "print", "help")
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	switch action {
+	case "print":
+		_, _ = fmt.Fprintln(w, "NAME\tENV\tVALUE\tSOURCE")
+		for _, e := range config.Entries() {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Name, e.EnvVar, e.Value, e.Source)
+		}
+	case "help":
+		// config values are set by flags named after them, env or config file keys of the same name
+		_, _ = fmt.Fprintln(w, "FLAG\tENV\tDEFAULT\tUSAGE")
+		for _, e := range config.Entries() {
+			_, _ = fmt.Fprintf(w, "-%s\t%s\t%s\t%s\n", e.Name, e.EnvVar, e.Default, e.Usage)
+		}
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
+	"github.com/csr-ugra/avito-estate-parser/internal/export"
+	"github.com/uptrace/bun"
+	"io"
+	"os"
+	"time"
+)
+
+// Export writes parsing values joined with tasks, locations and targets to file or stdout
+func Export(ctx context.Context, connection bun.IDB, args []string) error {
+	var filter db.ValueSeriesFilter
+	var formatName, out, dateFrom, dateTo string
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.StringVar(&formatName, "format", "csv", "output format: csv, xlsx (one sheet per location) or jsonl")
+	fs.StringVar(&out, "out", "-", "file to write to, - writes to stdout")
+	fs.IntVar(&filter.LocationId, "location-id", 0, "export only values of location, default: all")
+	fs.IntVar(&filter.TargetId, "target-id", 0, "export only values of target, default: all")
+	fs.StringVar(&dateFrom, "date-from", "", "export only date windows starting on or after date, eg. 2024-10-01")
+	fs.StringVar(&dateTo, "date-to", "", "export only date windows ending on or before date, eg. 2024-10-31")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := export.ParseFormat(formatName)
+	if err != nil {
+		return err
+	}
+
+	if filter.DateFrom, err = parseOptionalDate("date-from", dateFrom); err != nil {
+		return err
+	}
+	if filter.DateTo, err = parseOptionalDate("date-to", dateTo); err != nil {
+		return err
+	}
+
+	values, err := db.GetValueSeries(ctx, connection, filter)
+	if err != nil {
+		return err
+	}
+
+	if out == "-" {
+		return writeExport(os.Stdout, format, values)
+	}
+
+	file, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+
+	if err = writeExport(file, format, values); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	// stdout is kept clean for exported data
+	_, _ = fmt.Fprintf(os.Stderr, "exported %d values to %s\n", len(values), out)
+
+	return nil
+}
+
+func writeExport(w io.Writer, format export.Format, values []*db.ValueSeriesModel) error {
+	if err := export.Write(w, format, values); err != nil {
+		return fmt.Errorf("failed to export values: %v", err)
+	}
+
+	return nil
+}
+
+// returns nil when value is empty, date in YYYY-MM-DD format otherwise
+func parseOptionalDate(name string, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", name, err)
+	}
+
+	return &date, nil
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal"
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
+	"github.com/uptrace/bun"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Locations manages locations: locations add|list|rm
+func Locations(ctx context.Context, connection bun.IDB, args []string) error {
+	action, args, err := splitAction("locations", args, "add", "list", "rm")
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "add":
+		location := &db.EstateLocationModel{}
+		fs := flag.NewFlagSet("locations add", flag.ExitOnError)
+		fs.StringVar(&location.Name, "name", "", "location name as displayed by avito, eg. Сургут")
+		fs.StringVar(&location.UrlPart, "url-part", "", "location part of avito url, eg. surgut")
+		if err = fs.Parse(args); err != nil {
+			return err
+		}
+
+		if err = internal.AddLocation(ctx, connection, location); err != nil {
+			return err
+		}
+
+		fmt.Printf("added location %d\n", location.Id)
+	case "list":
+		locations, err := db.GetLocations(ctx, connection)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tNAME\tURL PART")
+		for _, l := range locations {
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", l.Id, l.Name, l.UrlPart)
+		}
+
+		return w.Flush()
+	case "rm":
+		id, err := parseId("locations rm", args)
+		if err != nil {
+			return err
+		}
+
+		if err = internal.RemoveLocation(ctx, connection, id); err != nil {
+			return err
+		}
+
+		fmt.Printf("removed location %d\n", id)
+	}
+
+	return nil
+}
+
+// Targets manages targets: targets add|list|rm
+func Targets(ctx context.Context, connection bun.IDB, args []string) error {
+	action, args, err := splitAction("targets", args, "add", "list", "rm")
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "add":
+		target := &db.EstateTargetModel{}
+		fs := flag.NewFlagSet("targets add", flag.ExitOnError)
+		fs.StringVar(&target.Name, "name", "", "target name")
+		fs.StringVar(&target.UrlPart, "url-part", "", "target part of avito url, eg. kvartiry/sdam/posutochno-ASgBAgICAkSSA8gQ8AeQUg")
+		fs.StringVar(&target.FilterText, "filter-text", "", "estate type as displayed in base estate widget filter, eg. Квартиры")
+		fs.StringVar(&target.SubfilterText, "subfilter-text", "", "estate subtype as displayed in widget filter")
+		if err = fs.Parse(args); err != nil {
+			return err
+		}
+
+		if err = internal.AddTarget(ctx, connection, target); err != nil {
+			return err
+		}
+
+		fmt.Printf("added target %d\n", target.Id)
+	case "list":
+		targets, err := db.GetTargets(ctx, connection)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tNAME\tURL PART\tFILTER TEXT\tSUBFILTER TEXT")
+		for _, t := range targets {
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", t.Id, t.Name, t.UrlPart, t.FilterText, t.SubfilterText)
+		}
+
+		return w.Flush()
+	case "rm":
+		id, err := parseId("targets rm", args)
+		if err != nil {
+			return err
+		}
+
+		if err = internal.RemoveTarget(ctx, connection, id); err != nil {
+			return err
+		}
+
+		fmt.Printf("removed target %d\n", id)
+	}
+
+	return nil
+}
+
+// Tasks manages parsing tasks: tasks add|list|enable|disable
+func Tasks(ctx context.Context, connection bun.IDB, args []string) error {
+	action, args, err := splitAction("tasks", args, "add", "list", "enable", "disable")
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "add":
+		task := &db.EstateParsingTaskModel{Enabled: true}
+		var retryBaseDelay time.Duration
+		fs := flag.NewFlagSet("tasks add", flag.ExitOnError)
+		fs.IntVar(&task.EstateLocationId, "location-id", 0, "id of task location")
+		fs.IntVar(&task.EstateTargetId, "target-id", 0, "id of task target")
+		fs.StringVar(&task.Description, "description", "", "task description")
+		fs.StringVar(&task.ValidateTitle, "validate-title", "", "expected title of estate list page, eg. Квартиры посуточно в Сургуте")
+		fs.BoolVar(&task.CrawlPages, "crawl-pages", false, "follow pagination to collect listings from every result page")
+		fs.IntVar(&task.CrawlPageLimit, "crawl-page-limit", 0, "max result pages to crawl, default: global limit")
+		fs.StringVar(&task.Schedule, "schedule", "", "cron schedule in serve mode, default: global schedule")
+		fs.IntVar(&task.RetryMaxAttempts, "retry-max-attempts", 0, "max attempts of task, default: global retry policy")
+		fs.DurationVar(&retryBaseDelay, "retry-base-delay", 0, "delay before the first retry of task, whole seconds, default: global retry policy")
+		if err = fs.Parse(args); err != nil {
+			return err
+		}
+		// stored in whole seconds, so fraction of a second would silently be lost
+		if retryBaseDelay%time.Second != 0 {
+			return fmt.Errorf("task retry base delay must be whole seconds, got %s", retryBaseDelay)
+		}
+		task.RetryBaseDelaySeconds = int(retryBaseDelay / time.Second)
+
+		if err = internal.AddTask(ctx, connection, task); err != nil {
+			return err
+		}
+
+		fmt.Printf("added task %d\n", task.Id)
+	case "list":
+		tasks, err := db.GetTasks(ctx, connection)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tLOCATION\tTARGET\tENABLED\tCRAWL\tSCHEDULE\tVALIDATE TITLE\tDESCRIPTION")
+		for _, t := range tasks {
+			_, _ = fmt.Fprintf(w, "%d\t%d\t%d\t%t\t%t\t%s\t%s\t%s\n",
+				t.Id, t.EstateLocationId, t.EstateTargetId, t.Enabled, t.CrawlPages, t.Schedule, t.ValidateTitle, t.Description)
+		}
+
+		return w.Flush()
+	case "enable", "disable":
+		id, err := parseId("tasks "+action, args)
+		if err != nil {
+			return err
+		}
+
+		if err = internal.SetTaskEnabled(ctx, connection, id, action == "enable"); err != nil {
+			return err
+		}
+
+		fmt.Printf("%sd task %d\n", action, id)
+	}
+
+	return nil
+}
+
+// returns subcommand action and the rest of arguments, action must be one of allowed
+func splitAction(command string, args []string, allowed ...string) (action string, rest []string, err error) {
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("usage: %s %v", command, allowed)
+	}
+
+	for _, a := range allowed {
+		if args[0] == a {
+			return a, args[1:], nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("unknown action %q, usage: %s %v", args[0], command, allowed)
+}
+
+// parses single positional id argument
+func parseId(command string, args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errors.New("usage: " + command + " <id>")
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q: %v", args[0], err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/db/migrations"
+	"github.com/csr-ugra/avito-estate-parser/internal/log"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+	"os"
+	"text/tabwriter"
+)
+
+// Migrate applies embedded schema migrations: migrate up|down|status,
+// down rolls back the last applied group of migrations
+func Migrate(ctx context.Context, connection *bun.DB, args []string) error {
+	action, _, err := splitAction("migrate", args, "up", "down", "status")
+	if err != nil {
+		return err
+	}
+
+	migrator := migrate.NewMigrator(connection, migrations.Migrations,
+		migrate.WithTableName("avito_estate_migrations"),
+		migrate.WithLocksTableName("avito_estate_migration_locks"))
+
+	if err = migrator.Init(ctx); err != nil {
+		return fmt.Errorf("failed to create migration tables: %v", err)
+	}
+
+	logger := log.GetLogger()
+
+	switch action {
+	case "up":
+		if err = migrator.Lock(ctx); err != nil {
+			return err
+		}
+		defer func() {
+			_ = migrator.Unlock(ctx)
+		}()
+
+		group, err := migrator.Migrate(ctx)
+		if err != nil {
+			return err
+		}
+
+		if group.IsZero() {
+			logger.Info("no new migrations to apply, database is up to date")
+			return nil
+		}
+
+		logger.WithField("Migrations", group.Migrations.String()).Infof("applied migration group %d", group.ID)
+	case "down":
+		if err = migrator.Lock(ctx); err != nil {
+			return err
+		}
+		defer func() {
+			_ = migrator.Unlock(ctx)
+		}()
+
+		group, err := migrator.Rollback(ctx)
+		if err != nil {
+			return err
+		}
+
+		if group.IsZero() {
+			logger.Info("no migrations to roll back")
+			return nil
+		}
+
+		logger.WithField("Migrations", group.Migrations.String()).Infof("rolled back migration group %d", group.ID)
+	case "status":
+		ms, err := migrator.MigrationsWithStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "MIGRATION\tCOMMENT\tGROUP\tAPPLIED AT")
+		for _, m := range ms {
+			if !m.IsApplied() {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t-\tpending\n", m.Name, m.Comment)
+				continue
+			}
+
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", m.Name, m.Comment, m.GroupID, m.MigratedAt.Format("2006-01-02 15:04:05"))
+		}
+
+		return w.Flush()
+	}
+
+	return nil
+}
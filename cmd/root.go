@@ -2,54 +2,268 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"github.com/csr-ugra/avito-estate-parser/internal"
+	"github.com/csr-ugra/avito-estate-parser/internal/anomaly"
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
 	"github.com/csr-ugra/avito-estate-parser/internal/log"
+	"github.com/csr-ugra/avito-estate-parser/internal/metrics"
 	"github.com/csr-ugra/avito-estate-parser/internal/parser"
+	"github.com/csr-ugra/avito-estate-parser/internal/selector"
 	"github.com/csr-ugra/avito-estate-parser/internal/util"
 	"github.com/sirupsen/logrus"
 	"github.com/uptrace/bun"
+	"slices"
+	"strings"
 	"time"
 )
 
-func Run(ctx context.Context, connection bun.IDB, config *util.Config) error {
-	var dryRun bool
-	flag.BoolVar(&dryRun, "dry", false, "dry run")
-	flag.String("date-start", time.Now().Add(24*time.Hour).Format(time.DateOnly), "start date, default: tomorrows date")
-	flag.String("date-end", "", "end date, default: the day after 'date-start'")
-	flag.Parse()
+// Run parses all tasks once, or dispatches to subcommand when one is given as first argument,
+// args must not contain program name and config flags
+func Run(ctx context.Context, config *util.Config, args []string) error {
+	// the only command that works without db
+	if len(args) > 0 && args[0] == "config" {
+		return Config(config, args[1:])
+	}
+
+	connection, err := db.GetConnection(config)
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "serve":
+			return Serve(ctx, connection, config, args[1:])
+		case "api":
+			return Api(ctx, connection, args[1:])
+		case "locations":
+			return Locations(ctx, connection, args[1:])
+		case "targets":
+			return Targets(ctx, connection, args[1:])
+		case "tasks":
+			return Tasks(ctx, connection, args[1:])
+		case "migrate":
+			return Migrate(ctx, connection, args[1:])
+		case "export":
+			return Export(ctx, connection, args[1:])
+		case "check-selectors":
+			return CheckSelectors(ctx, connection, config, args[1:])
+		}
+	}
+
+	var rf runFlags
+	rf.register(flag.CommandLine)
+	if err = flag.CommandLine.Parse(args); err != nil {
+		return err
+	}
+
+	if rf.dryRun {
+		log.AddGlobalField("DryRun", rf.dryRun)
+	}
+
+	err = runTasks(ctx, connection, config, &rf, nil)
+
+	// metrics are written even when run failed, failure counts are what they are for
+	if rf.metricsTextfile != "" {
+		if writeErr := metrics.WriteTextfile(rf.metricsTextfile); writeErr != nil {
+			log.GetLogger().WithError(writeErr).Error("failed to write metrics textfile")
+		}
+	}
+
+	return err
+}
+
+type runFlags struct {
+	dryRun bool
+	opts   parser.Options
+	window windowFlags
+	// selectors is selector config source, see internal.LoadSelectors
+	selectors string
+	// metricsTextfile is only used in one-shot mode, serve mode exposes metrics over http
+	metricsTextfile string
+}
 
+func (f *runFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.dryRun, "dry", false, "dry run")
+	fs.IntVar(&f.opts.Concurrency, "concurrency", 1, "number of tasks parsed at once")
+	fs.IntVar(&f.opts.CrawlPageLimit, "crawl-page-limit", 10, "max result pages visited for tasks with pagination crawl enabled, 0 for no cap")
+	fs.DurationVar(&f.opts.CrawlPageDelay, "crawl-page-delay", 3*time.Second, "pause before opening next result page")
+	fs.DurationVar(&f.opts.TaskDelay, "task-delay", 2*time.Second, "pause of worker between tasks")
+	fs.DurationVar(&f.opts.NavigationTimeout, "navigation-timeout", time.Minute, "max time to wait for page to load, 0 disables limit")
+	fs.IntVar(&f.opts.Retry.MaxAttempts, "retry-max-attempts", 3, "max attempts of task, including the first one")
+	fs.DurationVar(&f.opts.Retry.BaseDelay, "retry-base-delay", 2*time.Second, "delay before the first retry of task, doubles with every next one")
+	fs.DurationVar(&f.opts.Retry.MaxDelay, "retry-max-delay", time.Minute, "max delay before retry of task, 0 for no cap")
+	fs.Float64Var(&f.opts.Retry.Jitter, "retry-jitter", 0.2, "fraction of retry delay it is randomly changed by")
+	f.opts.Retry.FailFastKinds = parser.DefaultFailFastKinds
+	fs.Func("retry-fail-fast", "comma separated error kinds task is not retried on, default: config,target_filter_not_found", func(value string) error {
+		kinds, err := parseErrorKinds(value)
+		f.opts.Retry.FailFastKinds = kinds
+		return err
+	})
+	fs.IntVar(&f.opts.MaxBlockCount, "max-blocks", 5, "number of blocked task attempts after which run is aborted, 0 never aborts")
+	fs.DurationVar(&f.opts.BlockBackoff, "block-backoff", time.Minute, "pause of the run after first blocked task attempt, doubles with every next one")
+	fs.DurationVar(&f.opts.BlockBackoffMax, "block-backoff-max", 15*time.Minute, "max pause of the run after blocked task attempt, 0 for no cap")
+	fs.BoolVar(&f.opts.RejectSuspect, "reject-suspect", false, "fail task on suspect result instead of saving it marked with the reason")
+	fs.StringVar(&f.opts.SnapshotDir, "snapshot-dir", "", "directory to save page snapshots of failed task attempts to, default: disabled")
+	fs.StringVar(&f.opts.FixtureDir, "fixtures", "", "run against saved html pages from directory with fixtures.json instead of live browser")
+
+	fs.StringVar(&f.selectors, "selectors", "", "yaml or json file to load selectors from, 'db' to load them from db, default: built-in selectors")
+	fs.StringVar(&f.metricsTextfile, "metrics-textfile", "", "one-shot mode: path of node_exporter textfile to write metrics to, default: disabled")
+
+	fs.StringVar(&f.window.dateStart, "date-start", "", "start date, default: tomorrows date")
+	fs.StringVar(&f.window.dateEnd, "date-end", "", "end date, default: the day after 'date-start'")
+	fs.IntVar(&f.window.sweepDays, "sweep-days", 0, "sweep mode: number of check-in days starting at 'date-start', 0 disables sweep")
+	fs.StringVar(&f.window.sweepStays, "sweep-stays", "1", "sweep mode: comma separated stay lengths in nights, eg. 1,2,7")
+	fs.StringVar(&f.window.sweepWeekdays, "sweep-weekdays", "", "sweep mode: comma separated check-in weekdays, eg. fri,sat,sun, default: every day")
+}
+
+// parses comma separated error kinds, empty value gives no kinds
+func parseErrorKinds(value string) ([]internal.ErrorKind, error) {
+	var kinds []internal.ErrorKind
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+
+		kind, err := internal.ParseErrorKind(item)
+		if err != nil {
+			return nil, err
+		}
+
+		kinds = append(kinds, kind)
+	}
+
+	return kinds, nil
+}
+
+// parses tasks and saves results, only tasks with given ids are parsed when taskIds is not empty
+func runTasks(ctx context.Context, connection bun.IDB, config *util.Config, rf *runFlags, taskIds []int) error {
 	logger := log.GetLogger()
 
-	if dryRun {
-		logger = log.AddGlobalField("DryRun", dryRun)
+	windows, err := rf.window.windows()
+	if err != nil {
+		return err
 	}
+	logger.WithField("DateWindowCount", len(windows)).Debug("built date windows")
 
 	logger.Debug("retrieving tasks from db")
-	tasks, err := internal.LoadTasks(ctx, connection)
+	tasks, err := internal.LoadTasks(ctx, connection, windows)
 	if err != nil {
 		return err
 	}
+
+	if len(taskIds) > 0 {
+		tasks = slices.DeleteFunc(tasks, func(task *internal.ParsingTask) bool {
+			return !slices.Contains(taskIds, task.Id)
+		})
+	}
 	logger.WithField("TaskCount", len(tasks)).Info("retrieved tasks from db")
 
+	// loaded on every run, so serve mode picks up changed selectors without restart
+	if err = internal.LoadSelectors(ctx, connection, rf.selectors); err != nil {
+		return err
+	}
+	logger.WithField("SelectorVersion", selector.Version()).Info("using selectors version {SelectorVersion}")
+
+	run, err := internal.StartRun(ctx, connection, log.GetTraceId(), rf.dryRun, len(tasks))
+	if err != nil {
+		return err
+	}
+
+	// windows of the run replace ones its tasks were parsed for before
+	runTaskIds := make([]int, 0, len(tasks))
+	for _, task := range tasks {
+		runTaskIds = append(runTaskIds, task.Id)
+	}
+	metrics.ResetEstateCounts(slices.Compact(runTaskIds))
+
 	var results []*internal.ParsingTaskResult
-	results, err = parser.Start(ctx, config, tasks)
+	var failures []*internal.ParsingTaskFailure
+	results, failures, err = parser.Start(ctx, config, rf.opts, tasks)
 
+	// aborted run still has results and failures of tasks parsed before it, they are saved as usual
+	var abortErr error
+	if errors.Is(err, parser.ErrTooManyBlocks) {
+		abortErr, err = err, nil
+	}
+
+	// run is finished even when parser failed, so it does not look like it is still going;
+	// context may already be cancelled at this point, which must not prevent saving
+	finishErr := internal.FinishRun(context.WithoutCancel(ctx), connection, run, results, failures)
 	if err != nil {
 		return err
 	}
+	if finishErr != nil {
+		return finishErr
+	}
 
 	logger.Debug("saving parsing results to db")
-	if !dryRun {
-		affectedCount, err := internal.SaveTaskResults(ctx, connection, results)
+	if !rf.dryRun {
+		inserted, err := internal.SaveTaskResults(ctx, connection, run.Id, results)
 		if err != nil {
 			return err
 		}
 		logger.WithFields(logrus.Fields{
 			"ResultCount":      len(results),
-			"AffectedRowCount": affectedCount,
+			"AffectedRowCount": len(inserted),
 		}).Info("saved parsing results to db")
+
+		listingCount, err := internal.SaveTaskListings(ctx, connection, run.Id, results)
+		if err != nil {
+			return err
+		}
+		logger.WithField("AffectedRowCount", listingCount).Info("saved listings to db")
+
+		failureCount, err := internal.SaveTaskFailures(ctx, connection, run.Id, failures)
+		if err != nil {
+			return err
+		}
+		logger.WithField("AffectedRowCount", failureCount).Info("saved failed task attempts to db")
+
+		// detection goes last, so its failure does not lose data of the run;
+		// results skipped as already stored were checked when they were inserted
+		if err = detectAnomalies(ctx, connection, run.Id, inserted); err != nil {
+			return err
+		}
+	}
+
+	if abortErr != nil {
+		return abortErr
+	}
+
+	metrics.SetLastSuccess(time.Now())
+
+	return nil
+}
+
+// checks saved results against task history and records values rules found suspicious
+func detectAnomalies(ctx context.Context, connection bun.IDB, runId string, results []*internal.ParsingTaskResult) error {
+	logger := log.GetLogger()
+
+	anomalies, err := anomaly.Detect(ctx, connection, runId, results, logger)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range anomalies {
+		logger.WithFields(logrus.Fields{
+			"TaskId":    a.TaskId,
+			"DateStart": a.DateStart.Format(time.DateOnly),
+			"DateEnd":   a.DateEnd.Format(time.DateOnly),
+			"Rule":      a.RuleName,
+			"Metric":    a.Metric,
+			"Value":     a.Value,
+			"Message":   a.Message,
+		}).Warn("anomaly detected by rule {Rule}: {Message}")
+	}
+
+	affectedCount, err := anomaly.Save(ctx, connection, anomalies)
+	if err != nil {
+		return err
 	}
+	logger.WithField("AffectedRowCount", affectedCount).Info("saved anomalies to db")
 
 	return nil
 }
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/api"
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
+	"github.com/csr-ugra/avito-estate-parser/internal/log"
+	"github.com/csr-ugra/avito-estate-parser/internal/metrics"
+	"github.com/csr-ugra/avito-estate-parser/internal/util"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/uptrace/bun"
+	"math/rand"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+type serveFlags struct {
+	run      runFlags
+	schedule string
+	jitter   time.Duration
+	httpAddr string
+	// planRetryDelay is wait before next attempt to plan run when planning failed
+	planRetryDelay time.Duration
+}
+
+// Serve stays up and runs tasks on cron schedule, tasks with own schedule
+// in db run on it, the rest on global one. Runs are sequential: schedule is
+// computed after previous run completes, so runs never overlap and missed ticks are skipped.
+func Serve(ctx context.Context, connection bun.IDB, config *util.Config, args []string) error {
+	var sf serveFlags
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	sf.run.register(fs)
+	fs.StringVar(&sf.schedule, "schedule", "0 3 * * *", "global cron schedule for tasks without own schedule")
+	fs.DurationVar(&sf.jitter, "jitter", 5*time.Minute, "max random delay added to every planned run")
+	fs.DurationVar(&sf.planRetryDelay, "plan-retry-delay", time.Minute, "wait before planning next run again when there is nothing to plan or planning failed")
+	fs.StringVar(&sf.httpAddr, "http-addr", ":8080", "address to serve json api and /metrics on, empty disables http server")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if sf.run.dryRun {
+		log.AddGlobalField("DryRun", sf.run.dryRun)
+	}
+
+	globalSchedule, err := cron.ParseStandard(sf.schedule)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q: %v", sf.schedule, err)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logger := log.GetLogger().WithField("Mode", "serve")
+	if sf.httpAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/api/", api.NewHandler(connection))
+			mux.Handle("GET /metrics", metrics.Handler())
+
+			if err := listenAndServe(ctx, sf.httpAddr, mux); err != nil {
+				logger.WithError(err).Error("http server failed")
+			}
+		}()
+	}
+
+	logger.WithField("Schedule", sf.schedule).Info("starting scheduler")
+
+	for {
+		next, taskIds, err := planNextRun(ctx, connection, globalSchedule, time.Now(), logger)
+		if err != nil {
+			// db may be down for a while or tasks not added yet, neither must bring daemon down
+			logger.WithError(err).WithField("RetryDelay", sf.planRetryDelay.String()).
+				Error("failed to plan next run, trying again in {RetryDelay}")
+
+			select {
+			case <-ctx.Done():
+				logger.Info("stopping scheduler")
+				return nil
+			case <-time.After(sf.planRetryDelay):
+			}
+			continue
+		}
+
+		var delay time.Duration
+		if sf.jitter > 0 {
+			delay = time.Duration(rand.Int63n(int64(sf.jitter)))
+		}
+		planned := next.Add(delay)
+
+		logger.WithFields(logrus.Fields{
+			"NextRun":  planned.Format(time.RFC3339),
+			"TaskIds":  taskIds,
+			"Schedule": sf.schedule,
+		}).Info("next run planned at {NextRun}")
+
+		select {
+		case <-ctx.Done():
+			logger.Info("stopping scheduler")
+			return nil
+		case <-time.After(time.Until(planned)):
+		}
+
+		// every scheduled run gets its own trace, which is also its run id in db
+		log.StartTrace()
+		logger = log.GetLogger().WithField("Mode", "serve")
+
+		logger.WithField("TaskIds", taskIds).Info("starting scheduled run")
+		err = runTasks(ctx, connection, config, &sf.run, taskIds)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		if err != nil {
+			// failed run must not bring daemon down, next one may succeed
+			logger.WithError(err).Error("scheduled run failed")
+			continue
+		}
+		logger.Info("scheduled run completed")
+	}
+}
+
+// returns earliest planned run time after given time and ids of tasks due at it,
+// schedules of tasks are re-read every time so changes in db are picked up without restart;
+// task with invalid schedule is logged and left out, so it does not stop the others
+func planNextRun(ctx context.Context, connection bun.IDB, globalSchedule cron.Schedule, after time.Time, log log.Logger) (next time.Time, taskIds []int, err error) {
+	tasks, err := db.GetEnabledTasks(ctx, connection)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if len(tasks) == 0 {
+		return time.Time{}, nil, errors.New("no tasks specified")
+	}
+
+	for _, task := range tasks {
+		schedule := globalSchedule
+		if task.Schedule != "" {
+			var parseErr error
+			schedule, parseErr = cron.ParseStandard(task.Schedule)
+			if parseErr != nil {
+				log.WithError(parseErr).WithFields(logrus.Fields{
+					"TaskId":   task.Id,
+					"Schedule": task.Schedule,
+				}).Error("invalid schedule {Schedule} of task {TaskId}, skipping task")
+				continue
+			}
+		}
+
+		taskNext := schedule.Next(after)
+		switch {
+		case next.IsZero() || taskNext.Before(next):
+			next = taskNext
+			taskIds = []int{task.Id}
+		case taskNext.Equal(next):
+			taskIds = append(taskIds, task.Id)
+		}
+	}
+
+	if len(taskIds) == 0 {
+		return time.Time{}, nil, errors.New("no task has valid schedule")
+	}
+
+	return next, taskIds, nil
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal"
+	"github.com/csr-ugra/avito-estate-parser/internal/util"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type windowFlags struct {
+	dateStart     string
+	dateEnd       string
+	sweepDays     int
+	sweepStays    string
+	sweepWeekdays string
+}
+
+// builds date windows from flags: either a single date-start/date-end pair
+// or, when sweep-days is set, a sweep over the horizon starting at date-start,
+// date-start defaults to tomorrow at the moment of call so daemon runs move forward
+func (f *windowFlags) windows() ([]internal.DateWindow, error) {
+	dateStartValue := f.dateStart
+	if dateStartValue == "" {
+		dateStartValue = time.Now().Add(24 * time.Hour).Format(time.DateOnly)
+	}
+
+	dateStart, err := time.Parse(time.DateOnly, dateStartValue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date-start: %v", err)
+	}
+
+	if f.sweepDays > 0 {
+		return f.sweepWindows(dateStart)
+	}
+
+	dateEnd := dateStart.Add(24 * time.Hour)
+	if f.dateEnd != "" {
+		dateEnd, err = time.Parse(time.DateOnly, f.dateEnd)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date-end: %v", err)
+		}
+	}
+
+	window, err := internal.NewDateWindow(dateStart, dateEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	return []internal.DateWindow{window}, nil
+}
+
+func (f *windowFlags) sweepWindows(from time.Time) ([]internal.DateWindow, error) {
+	opts := internal.SweepOptions{
+		From: from,
+		Days: f.sweepDays,
+	}
+
+	for _, s := range splitList(f.sweepStays) {
+		stay, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sweep stay length %q: %v", s, err)
+		}
+
+		opts.StayLengths = append(opts.StayLengths, stay)
+	}
+
+	for _, s := range splitList(f.sweepWeekdays) {
+		weekday, err := util.ParseWeekday(s)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.Weekdays = append(opts.Weekdays, weekday)
+	}
+
+	return internal.GenerateSweepWindows(opts)
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+
+	return list
+}
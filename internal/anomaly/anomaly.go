@@ -0,0 +1,129 @@
+package anomaly
+
+import (
+	"context"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal"
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
+	"github.com/csr-ugra/avito-estate-parser/internal/log"
+	"github.com/uptrace/bun"
+	"time"
+)
+
+// Detect checks results of run against history of their tasks using enabled rules from db,
+// history is taken from date windows of the same length, so weekend stays are not compared to weekly ones;
+// rule that can't be evaluated, eg. zscore one without threshold, is logged and skipped
+func Detect(ctx context.Context, connection bun.IDB, runId string, results []*internal.ParsingTaskResult, log log.Logger) ([]*db.EstateAnomalyModel, error) {
+	rules, err := db.GetEnabledAnomalyRules(ctx, connection)
+	if err != nil {
+		return nil, fmt.Errorf("error getting anomaly rules: %v", err)
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	anomalies := make([]*db.EstateAnomalyModel, 0)
+	// broken rules are reported once, not for every result
+	isBroken := make(map[int]bool)
+	for _, result := range results {
+		taskRules := rulesOfTask(rules, result.Task.Id)
+		if len(taskRules) == 0 {
+			continue
+		}
+
+		historyLimit := 0
+		for _, rule := range taskRules {
+			historyLimit = max(historyLimit, rule.History)
+		}
+
+		var history []*db.EstateParsingObservationModel
+		if historyLimit > 0 {
+			nights := int(result.Task.DateEnd.Sub(*result.Task.DateStart) / (24 * time.Hour))
+			history, err = db.GetObservationHistory(ctx, connection, result.Task.Id, nights, runId, historyLimit)
+			if err != nil {
+				return nil, fmt.Errorf("error getting history of task %d: %v", result.Task.Id, err)
+			}
+		}
+
+		for _, rule := range taskRules {
+			if isBroken[rule.Id] {
+				continue
+			}
+
+			anomaly, err := checkResult(rule, result, history)
+			if err != nil {
+				isBroken[rule.Id] = true
+				log.WithError(err).WithField("Rule", rule.Name).Error("anomaly rule {Rule} is broken, skipping it")
+				continue
+			}
+
+			if anomaly != nil {
+				anomaly.RunId = runId
+				anomalies = append(anomalies, anomaly)
+			}
+		}
+	}
+
+	return anomalies, nil
+}
+
+func Save(ctx context.Context, connection bun.IDB, anomalies []*db.EstateAnomalyModel) (int, error) {
+	insertedCount, err := db.SaveAnomalies(ctx, connection, anomalies)
+	if err != nil {
+		return 0, fmt.Errorf("error savings anomalies: %v", err)
+	}
+
+	return insertedCount, nil
+}
+
+// returns rules that apply to every task along with ones of given task
+func rulesOfTask(rules []*db.EstateAnomalyRuleModel, taskId int) []*db.EstateAnomalyRuleModel {
+	taskRules := make([]*db.EstateAnomalyRuleModel, 0, len(rules))
+	for _, rule := range rules {
+		if rule.TaskId == 0 || rule.TaskId == taskId {
+			taskRules = append(taskRules, rule)
+		}
+	}
+
+	return taskRules
+}
+
+func checkResult(rule *db.EstateAnomalyRuleModel, result *internal.ParsingTaskResult, history []*db.EstateParsingObservationModel) (*db.EstateAnomalyModel, error) {
+	value, ok, err := metricOf(rule.Metric, result.EstateTotalCount, result.EstateFreeCount)
+	if err != nil {
+		return nil, fmt.Errorf("anomaly rule %q: %v", rule.Name, err)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	if len(history) > rule.History {
+		history = history[:rule.History]
+	}
+
+	historyValues := make([]float64, 0, len(history))
+	for _, h := range history {
+		v, ok, _ := metricOf(rule.Metric, h.EstateTotalCount, h.EstateFreeCount)
+		if ok {
+			historyValues = append(historyValues, v)
+		}
+	}
+
+	f, err := check(rule, value, historyValues)
+	if err != nil || f == nil {
+		return nil, err
+	}
+
+	return &db.EstateAnomalyModel{
+		TaskId:    result.Task.Id,
+		DateStart: result.Task.DateStart,
+		DateEnd:   result.Task.DateEnd,
+		RuleId:    rule.Id,
+		RuleName:  rule.Name,
+		Metric:    rule.Metric,
+		Value:     value,
+		Expected:  f.expected,
+		Score:     f.score,
+		Message:   f.message,
+	}, nil
+}
@@ -0,0 +1,146 @@
+package anomaly
+
+import (
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
+	"math"
+)
+
+const (
+	KindZScore    = "zscore"
+	KindPctChange = "pct_change"
+	KindBounds    = "bounds"
+)
+
+const (
+	MetricTotalCount    = "total_count"
+	MetricFreeCount     = "free_count"
+	MetricOccupancyRate = "occupancy_rate"
+)
+
+// minimal history z-score is computed over, fewer values give meaningless deviation
+const minZScoreHistory = 5
+
+// finding is what fired rule found, fields match anomaly model
+type finding struct {
+	expected *float64
+	score    *float64
+	message  string
+}
+
+// checks value against history ordered newest first, returns nil when value looks fine
+func check(rule *db.EstateAnomalyRuleModel, value float64, history []float64) (*finding, error) {
+	switch rule.Kind {
+	case KindZScore:
+		return checkZScore(rule, value, history)
+	case KindPctChange:
+		return checkPctChange(rule, value, history)
+	case KindBounds:
+		return checkBounds(rule, value)
+	}
+
+	return nil, fmt.Errorf("unknown kind %q of anomaly rule %q", rule.Kind, rule.Name)
+}
+
+// fires when value is more than threshold standard deviations away from history mean
+func checkZScore(rule *db.EstateAnomalyRuleModel, value float64, history []float64) (*finding, error) {
+	if rule.Threshold == nil {
+		return nil, fmt.Errorf("anomaly rule %q has no threshold", rule.Name)
+	}
+
+	if len(history) < minZScoreHistory {
+		return nil, nil
+	}
+
+	var sum float64
+	for _, h := range history {
+		sum += h
+	}
+	mean := sum / float64(len(history))
+
+	var squares float64
+	for _, h := range history {
+		squares += (h - mean) * (h - mean)
+	}
+	deviation := math.Sqrt(squares / float64(len(history)))
+
+	// constant history gives no scale to measure against
+	if deviation == 0 {
+		return nil, nil
+	}
+
+	score := (value - mean) / deviation
+	if math.Abs(score) <= *rule.Threshold {
+		return nil, nil
+	}
+
+	return &finding{
+		expected: &mean,
+		score:    &score,
+		message:  fmt.Sprintf("%s %g is %.1f standard deviations from mean %.1f", rule.Metric, value, score, mean),
+	}, nil
+}
+
+// fires when value changed by more than threshold share of the previous value
+func checkPctChange(rule *db.EstateAnomalyRuleModel, value float64, history []float64) (*finding, error) {
+	if rule.Threshold == nil {
+		return nil, fmt.Errorf("anomaly rule %q has no threshold", rule.Name)
+	}
+
+	if len(history) == 0 || history[0] == 0 {
+		return nil, nil
+	}
+
+	previous := history[0]
+	change := (value - previous) / previous
+	if math.Abs(change) <= *rule.Threshold {
+		return nil, nil
+	}
+
+	return &finding{
+		expected: &previous,
+		score:    &change,
+		message:  fmt.Sprintf("%s changed by %+.0f%% from %g to %g", rule.Metric, change*100, previous, value),
+	}, nil
+}
+
+// fires when value is outside of [min, max], missing bound is not checked
+func checkBounds(rule *db.EstateAnomalyRuleModel, value float64) (*finding, error) {
+	if rule.MinValue == nil && rule.MaxValue == nil {
+		return nil, fmt.Errorf("anomaly rule %q has neither min nor max value", rule.Name)
+	}
+
+	if rule.MinValue != nil && value < *rule.MinValue {
+		return &finding{
+			expected: rule.MinValue,
+			message:  fmt.Sprintf("%s %g is below %g", rule.Metric, value, *rule.MinValue),
+		}, nil
+	}
+
+	if rule.MaxValue != nil && value > *rule.MaxValue {
+		return &finding{
+			expected: rule.MaxValue,
+			message:  fmt.Sprintf("%s %g is above %g", rule.Metric, value, *rule.MaxValue),
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// returns metric of value, false when metric is not defined for it
+func metricOf(metric string, totalCount int, freeCount int) (float64, bool, error) {
+	switch metric {
+	case MetricTotalCount:
+		return float64(totalCount), true, nil
+	case MetricFreeCount:
+		return float64(freeCount), true, nil
+	case MetricOccupancyRate:
+		if totalCount <= 0 {
+			return 0, false, nil
+		}
+
+		return 1 - float64(freeCount)/float64(totalCount), true, nil
+	}
+
+	return 0, false, fmt.Errorf("unknown anomaly metric %q", metric)
+}
@@ -0,0 +1,226 @@
+package anomaly
+
+import (
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
+	"math"
+	"testing"
+)
+
+func ptr(v float64) *float64 {
+	return &v
+}
+
+func TestCheck(t *testing.T) {
+	// mean 11, standard deviation 1
+	steadyHistory := []float64{10, 12, 10, 12, 10, 12}
+
+	tests := []struct {
+		name      string
+		rule      *db.EstateAnomalyRuleModel
+		value     float64
+		history   []float64
+		wantFired bool
+		// checked only when rule fired
+		wantExpected *float64
+		wantScore    *float64
+		wantErr      bool
+	}{
+		{
+			name:         "zscore above threshold",
+			rule:         &db.EstateAnomalyRuleModel{Kind: KindZScore, Threshold: ptr(3)},
+			value:        15,
+			history:      steadyHistory,
+			wantFired:    true,
+			wantExpected: ptr(11),
+			wantScore:    ptr(4),
+		},
+		{
+			name:         "zscore below mean",
+			rule:         &db.EstateAnomalyRuleModel{Kind: KindZScore, Threshold: ptr(3)},
+			value:        7,
+			history:      steadyHistory,
+			wantFired:    true,
+			wantExpected: ptr(11),
+			wantScore:    ptr(-4),
+		},
+		{
+			name:    "zscore within threshold",
+			rule:    &db.EstateAnomalyRuleModel{Kind: KindZScore, Threshold: ptr(3)},
+			value:   13,
+			history: steadyHistory,
+		},
+		{
+			name:    "zscore with too short history",
+			rule:    &db.EstateAnomalyRuleModel{Kind: KindZScore, Threshold: ptr(3)},
+			value:   100,
+			history: []float64{10, 12, 10, 12},
+		},
+		{
+			name:    "zscore with constant history",
+			rule:    &db.EstateAnomalyRuleModel{Kind: KindZScore, Threshold: ptr(3)},
+			value:   100,
+			history: []float64{10, 10, 10, 10, 10},
+		},
+		{
+			name:    "zscore without threshold",
+			rule:    &db.EstateAnomalyRuleModel{Kind: KindZScore},
+			value:   100,
+			history: steadyHistory,
+			wantErr: true,
+		},
+		{
+			name:         "pct change above threshold",
+			rule:         &db.EstateAnomalyRuleModel{Kind: KindPctChange, Threshold: ptr(0.5)},
+			value:        40,
+			history:      []float64{100, 10},
+			wantFired:    true,
+			wantExpected: ptr(100),
+			wantScore:    ptr(-0.6),
+		},
+		{
+			name:    "pct change within threshold",
+			rule:    &db.EstateAnomalyRuleModel{Kind: KindPctChange, Threshold: ptr(0.5)},
+			value:   140,
+			history: []float64{100},
+		},
+		{
+			name:    "pct change from zero",
+			rule:    &db.EstateAnomalyRuleModel{Kind: KindPctChange, Threshold: ptr(0.5)},
+			value:   140,
+			history: []float64{0},
+		},
+		{
+			name:  "pct change without history",
+			rule:  &db.EstateAnomalyRuleModel{Kind: KindPctChange, Threshold: ptr(0.5)},
+			value: 140,
+		},
+		{
+			name:    "pct change without threshold",
+			rule:    &db.EstateAnomalyRuleModel{Kind: KindPctChange},
+			value:   140,
+			history: []float64{100},
+			wantErr: true,
+		},
+		{
+			name:         "bounds below min",
+			rule:         &db.EstateAnomalyRuleModel{Kind: KindBounds, MinValue: ptr(0.01), MaxValue: ptr(1)},
+			value:        0,
+			wantFired:    true,
+			wantExpected: ptr(0.01),
+		},
+		{
+			name:         "bounds above max",
+			rule:         &db.EstateAnomalyRuleModel{Kind: KindBounds, MinValue: ptr(0.01), MaxValue: ptr(1)},
+			value:        1.5,
+			wantFired:    true,
+			wantExpected: ptr(1),
+		},
+		{
+			name:  "bounds within",
+			rule:  &db.EstateAnomalyRuleModel{Kind: KindBounds, MinValue: ptr(0.01), MaxValue: ptr(1)},
+			value: 0.5,
+		},
+		{
+			name:  "bounds with max only",
+			rule:  &db.EstateAnomalyRuleModel{Kind: KindBounds, MaxValue: ptr(1)},
+			value: -5,
+		},
+		{
+			name:    "bounds without min and max",
+			rule:    &db.EstateAnomalyRuleModel{Kind: KindBounds},
+			value:   0.5,
+			wantErr: true,
+		},
+		{
+			name:    "unknown kind",
+			rule:    &db.EstateAnomalyRuleModel{Kind: "median"},
+			value:   0.5,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Name = tt.name
+			tt.rule.Metric = MetricFreeCount
+
+			f, err := check(tt.rule, tt.value, tt.history)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got finding %+v", f)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !tt.wantFired {
+				if f != nil {
+					t.Fatalf("expected rule not to fire, got %q", f.message)
+				}
+				return
+			}
+
+			if f == nil {
+				t.Fatal("expected rule to fire")
+			}
+			if f.message == "" {
+				t.Error("expected finding to have message")
+			}
+			if !equalPtr(f.expected, tt.wantExpected) {
+				t.Errorf("expected expected value %v, got %v", deref(tt.wantExpected), deref(f.expected))
+			}
+			if tt.wantScore != nil && !equalPtr(f.score, tt.wantScore) {
+				t.Errorf("expected score %v, got %v", deref(tt.wantScore), deref(f.score))
+			}
+		})
+	}
+}
+
+func TestMetricOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		metric  string
+		total   int
+		free    int
+		want    float64
+		wantOk  bool
+		wantErr bool
+	}{
+		{name: "total count", metric: MetricTotalCount, total: 120, free: 30, want: 120, wantOk: true},
+		{name: "free count", metric: MetricFreeCount, total: 120, free: 30, want: 30, wantOk: true},
+		{name: "occupancy rate", metric: MetricOccupancyRate, total: 120, free: 30, want: 0.75, wantOk: true},
+		{name: "occupancy rate without listings", metric: MetricOccupancyRate, total: 0, free: 0},
+		{name: "unknown metric", metric: "price", total: 120, free: 30, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := metricOf(tt.metric, tt.total, tt.free)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("expected %v, %v, got %v, %v", tt.want, tt.wantOk, got, ok)
+			}
+		})
+	}
+}
+
+func equalPtr(a *float64, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return math.Abs(*a-*b) < 1e-9
+}
+
+func deref(v *float64) any {
+	if v == nil {
+		return nil
+	}
+
+	return *v
+}
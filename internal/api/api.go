@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
+	"github.com/csr-ugra/avito-estate-parser/internal/log"
+	"github.com/uptrace/bun"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type server struct {
+	connection bun.IDB
+}
+
+// NewHandler returns json api over parsing results:
+//
+//	GET /api/locations
+//	GET /api/targets
+//	GET /api/tasks
+//	GET /api/values?location_id=&target_id=&task_id=&date_from=&date_to=&observed_from=&observed_to=
+//
+// dates are in YYYY-MM-DD format, observed_to is exclusive
+func NewHandler(connection bun.IDB) http.Handler {
+	s := &server{connection: connection}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/locations", s.getLocations)
+	mux.HandleFunc("GET /api/targets", s.getTargets)
+	mux.HandleFunc("GET /api/tasks", s.getTasks)
+	mux.HandleFunc("GET /api/values", s.getValues)
+
+	return mux
+}
+
+func (s *server) getLocations(w http.ResponseWriter, r *http.Request) {
+	locations, err := db.GetLocations(r.Context(), s.connection)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJson(w, locations)
+}
+
+func (s *server) getTargets(w http.ResponseWriter, r *http.Request) {
+	targets, err := db.GetTargets(r.Context(), s.connection)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJson(w, targets)
+}
+
+func (s *server) getTasks(w http.ResponseWriter, r *http.Request) {
+	tasks, err := db.GetTasks(r.Context(), s.connection)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJson(w, tasks)
+}
+
+func (s *server) getValues(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseValueSeriesFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	values, err := db.GetValueSeries(r.Context(), s.connection, filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJson(w, values)
+}
+
+func parseValueSeriesFilter(r *http.Request) (filter db.ValueSeriesFilter, err error) {
+	query := r.URL.Query()
+
+	ids := map[string]*int{
+		"location_id": &filter.LocationId,
+		"target_id":   &filter.TargetId,
+		"task_id":     &filter.TaskId,
+	}
+	for name, value := range ids {
+		if query.Get(name) == "" {
+			continue
+		}
+
+		*value, err = strconv.Atoi(query.Get(name))
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s: %v", name, err)
+		}
+	}
+
+	dates := map[string]**time.Time{
+		"date_from":     &filter.DateFrom,
+		"date_to":       &filter.DateTo,
+		"observed_from": &filter.ObservedFrom,
+		"observed_to":   &filter.ObservedTo,
+	}
+	for name, value := range dates {
+		if query.Get(name) == "" {
+			continue
+		}
+
+		date, err := time.Parse(time.DateOnly, query.Get(name))
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s: %v", name, err)
+		}
+
+		*value = &date
+	}
+
+	return filter, nil
+}
+
+func writeJson(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.GetLogger().WithError(err).Warn("failed to write api response")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		log.GetLogger().WithError(err).Error("api request failed")
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+// BlockedError is returned when avito serves block or captcha page instead of requested one
+type BlockedError struct {
+	Url    string
+	Reason string
+}
+
+func NewBlockedError(url string, reason string) *BlockedError {
+	return &BlockedError{Url: url, Reason: reason}
+}
+
+func (e BlockedError) Error() string {
+	return fmt.Sprintf("access to %s is blocked: %s", e.Url, e.Reason)
+}
+
+func (e BlockedError) Is(target error) bool {
+	var t *BlockedError
+	ok := errors.As(target, &t)
+	return ok
+}
+
+func (e BlockedError) Kind() ErrorKind {
+	return ErrorKindBlocked
+}
@@ -0,0 +1,192 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
+	"github.com/robfig/cron/v3"
+	"github.com/uptrace/bun"
+	"strings"
+)
+
+// AddLocation validates and stores location, checks match the ones buildUrl does at run time
+func AddLocation(ctx context.Context, connection bun.IDB, location *db.EstateLocationModel) error {
+	if strings.TrimSpace(location.Name) == "" {
+		return errors.New("location name must not be empty")
+	}
+
+	if strings.TrimSpace(location.UrlPart) == "" {
+		return errors.New("location url part must not be empty")
+	}
+
+	return db.InsertLocation(ctx, connection, location)
+}
+
+// RemoveLocation deletes location unless some task still refers to it
+func RemoveLocation(ctx context.Context, connection bun.IDB, id int) error {
+	taskCount, err := db.GetTaskCountByLocation(ctx, connection, id)
+	if err != nil {
+		return err
+	}
+
+	if taskCount > 0 {
+		return fmt.Errorf("location with id %d is used by %d tasks", id, taskCount)
+	}
+
+	affectedCount, err := db.DeleteLocation(ctx, connection, id)
+	if err != nil {
+		return err
+	}
+
+	if affectedCount == 0 {
+		return fmt.Errorf("location with id %d not found", id)
+	}
+
+	return nil
+}
+
+// AddTarget validates and stores target, filter text is required
+// since it's used to pick estate type on base estate widget
+func AddTarget(ctx context.Context, connection bun.IDB, target *db.EstateTargetModel) error {
+	if strings.TrimSpace(target.Name) == "" {
+		return errors.New("target name must not be empty")
+	}
+
+	if strings.TrimSpace(target.UrlPart) == "" {
+		return errors.New("target url part must not be empty")
+	}
+
+	if strings.TrimSpace(target.FilterText) == "" {
+		return errors.New("target filter text must not be empty")
+	}
+
+	return db.InsertTarget(ctx, connection, target)
+}
+
+// RemoveTarget deletes target unless some task still refers to it
+func RemoveTarget(ctx context.Context, connection bun.IDB, id int) error {
+	taskCount, err := db.GetTaskCountByTarget(ctx, connection, id)
+	if err != nil {
+		return err
+	}
+
+	if taskCount > 0 {
+		return fmt.Errorf("target with id %d is used by %d tasks", id, taskCount)
+	}
+
+	affectedCount, err := db.DeleteTarget(ctx, connection, id)
+	if err != nil {
+		return err
+	}
+
+	if affectedCount == 0 {
+		return fmt.Errorf("target with id %d not found", id)
+	}
+
+	return nil
+}
+
+// AddTask validates and stores task, checks match the ones NewParsingTask does at run time
+func AddTask(ctx context.Context, connection bun.IDB, task *db.EstateParsingTaskModel) error {
+	if strings.TrimSpace(task.ValidateTitle) == "" {
+		return errors.New("task validate title must not be empty")
+	}
+
+	if task.CrawlPageLimit < 0 {
+		return fmt.Errorf("task crawl page limit must not be negative, got %d", task.CrawlPageLimit)
+	}
+
+	if task.RetryMaxAttempts < 0 {
+		return fmt.Errorf("task retry max attempts must not be negative, got %d", task.RetryMaxAttempts)
+	}
+
+	if task.RetryBaseDelaySeconds < 0 {
+		return fmt.Errorf("task retry base delay must not be negative, got %ds", task.RetryBaseDelaySeconds)
+	}
+
+	if task.Schedule != "" {
+		if _, err := cron.ParseStandard(task.Schedule); err != nil {
+			return fmt.Errorf("invalid task schedule %q: %v", task.Schedule, err)
+		}
+	}
+
+	location, err := db.GetLocationById(ctx, connection, task.EstateLocationId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("location with id %d not found", task.EstateLocationId)
+	}
+	if err != nil {
+		return err
+	}
+
+	target, err := db.GetTargetById(ctx, connection, task.EstateTargetId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("target with id %d not found", task.EstateTargetId)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err = buildUrl(location, target); err != nil {
+		return err
+	}
+
+	return db.InsertTask(ctx, connection, task)
+}
+
+// SampleUrls returns url of task and of base estate page of its location,
+// first enabled task is used when taskId is zero
+func SampleUrls(ctx context.Context, connection bun.IDB, taskId int) (taskUrl string, baseEstateUrl string, err error) {
+	tasks, err := db.GetEnabledTasks(ctx, connection)
+	if err != nil {
+		return "", "", err
+	}
+
+	var task *db.EstateParsingTaskModel
+	for _, t := range tasks {
+		if taskId == 0 || t.Id == taskId {
+			task = t
+			break
+		}
+	}
+	if task == nil {
+		if taskId == 0 {
+			return "", "", errors.New("no tasks specified")
+		}
+		return "", "", fmt.Errorf("enabled task with id %d not found", taskId)
+	}
+
+	location, err := db.GetLocationById(ctx, connection, task.EstateLocationId)
+	if err != nil {
+		return "", "", err
+	}
+
+	target, err := db.GetTargetById(ctx, connection, task.EstateTargetId)
+	if err != nil {
+		return "", "", err
+	}
+
+	if taskUrl, err = buildUrl(location, target); err != nil {
+		return "", "", err
+	}
+
+	if baseEstateUrl, err = buildBaseEstateUrl(location); err != nil {
+		return "", "", err
+	}
+
+	return taskUrl, baseEstateUrl, nil
+}
+
+func SetTaskEnabled(ctx context.Context, connection bun.IDB, id int, enabled bool) error {
+	affectedCount, err := db.SetTaskEnabled(ctx, connection, id, enabled)
+	if err != nil {
+		return err
+	}
+
+	if affectedCount == 0 {
+		return fmt.Errorf("task with id %d not found", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ConfigError is returned when location, target or task stored in db can't be used as is,
+// trying again won't help until it is fixed
+type ConfigError struct {
+	Reason string
+}
+
+func NewConfigError(reason string) *ConfigError {
+	return &ConfigError{Reason: reason}
+}
+
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("invalid task config: %s", e.Reason)
+}
+
+func (e ConfigError) Is(target error) bool {
+	var t *ConfigError
+	ok := errors.As(target, &t)
+	return ok
+}
+
+func (e ConfigError) Kind() ErrorKind {
+	return ErrorKindConfig
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/selector"
+)
+
+type CountParseError struct {
+	Selector string
+	Text     string
+	Err      error
+}
+
+func NewCountParseError(selector selector.Selector, text string, err error) *CountParseError {
+	return &CountParseError{Selector: string(selector), Text: text, Err: err}
+}
+
+func (e CountParseError) Error() string {
+	return fmt.Sprintf("failed to parse count %q of element '%s': %v", e.Text, e.Selector, e.Err)
+}
+
+func (e CountParseError) Is(target error) bool {
+	var t *CountParseError
+	ok := errors.As(target, &t)
+	return ok
+}
+
+func (e CountParseError) Unwrap() error {
+	return e.Err
+}
+
+func (e CountParseError) Kind() ErrorKind {
+	return ErrorKindCountParse
+}
+
+func (e CountParseError) SelectorString() string {
+	return e.Selector
+}
@@ -3,11 +3,13 @@ package db
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"github.com/csr-ugra/avito-estate-parser/internal/util"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/driver/pgdriver"
 	"github.com/uptrace/bun/extra/bundebug"
+	"time"
 )
 
 func GetConnection(config *util.Config) (*bun.DB, error) {
@@ -34,24 +36,241 @@ func GetTasks(ctx context.Context, connection bun.IDB) (tasks []*EstateParsingTa
 	return tasks, err
 }
 
+// GetEnabledTasks returns tasks to be parsed, disabled tasks are kept in db but skipped
+func GetEnabledTasks(ctx context.Context, connection bun.IDB) (tasks []*EstateParsingTaskModel, err error) {
+	err = connection.NewSelect().Model(&tasks).Where("enabled").Order("id").Scan(ctx)
+
+	return tasks, err
+}
+
+func GetTaskCountByLocation(ctx context.Context, connection bun.IDB, locationId int) (int, error) {
+	return connection.NewSelect().Model((*EstateParsingTaskModel)(nil)).
+		Where("avito_estate_location_id = ?", locationId).
+		Count(ctx)
+}
+
+func GetTaskCountByTarget(ctx context.Context, connection bun.IDB, targetId int) (int, error) {
+	return connection.NewSelect().Model((*EstateParsingTaskModel)(nil)).
+		Where("avito_estate_target_id = ?", targetId).
+		Count(ctx)
+}
+
+func InsertTask(ctx context.Context, connection bun.IDB, task *EstateParsingTaskModel) error {
+	_, err := connection.NewInsert().Model(task).Returning("*").Exec(ctx)
+
+	return err
+}
+
+func SetTaskEnabled(ctx context.Context, connection bun.IDB, id int, enabled bool) (affectedCount int, err error) {
+	res, err := connection.NewUpdate().Model((*EstateParsingTaskModel)(nil)).
+		Set("enabled = ?", enabled).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	c, err := res.RowsAffected()
+
+	return int(c), err
+}
+
 func GetLocations(ctx context.Context, connection bun.IDB) (locations []*EstateLocationModel, err error) {
 	err = connection.NewSelect().Model(&locations).Scan(ctx)
 
 	return locations, err
 }
 
+func GetLocationById(ctx context.Context, connection bun.IDB, id int) (location *EstateLocationModel, err error) {
+	location = new(EstateLocationModel)
+	err = connection.NewSelect().Model(location).Where("id = ?", id).Scan(ctx)
+
+	return location, err
+}
+
+func InsertLocation(ctx context.Context, connection bun.IDB, location *EstateLocationModel) error {
+	_, err := connection.NewInsert().Model(location).Returning("*").Exec(ctx)
+
+	return err
+}
+
+func DeleteLocation(ctx context.Context, connection bun.IDB, id int) (affectedCount int, err error) {
+	res, err := connection.NewDelete().Model((*EstateLocationModel)(nil)).Where("id = ?", id).Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	c, err := res.RowsAffected()
+
+	return int(c), err
+}
+
 func GetTargets(ctx context.Context, connection bun.IDB) (targets []*EstateTargetModel, err error) {
 	err = connection.NewSelect().Model(&targets).Scan(ctx)
 
 	return targets, err
 }
 
-func SaveValues(ctx context.Context, connection bun.IDB, values []*EstateParsingValueModel) (affectedCount int, err error) {
+func GetTargetById(ctx context.Context, connection bun.IDB, id int) (target *EstateTargetModel, err error) {
+	target = new(EstateTargetModel)
+	err = connection.NewSelect().Model(target).Where("id = ?", id).Scan(ctx)
+
+	return target, err
+}
+
+func InsertTarget(ctx context.Context, connection bun.IDB, target *EstateTargetModel) error {
+	_, err := connection.NewInsert().Model(target).Returning("*").Exec(ctx)
+
+	return err
+}
+
+func DeleteTarget(ctx context.Context, connection bun.IDB, id int) (affectedCount int, err error) {
+	res, err := connection.NewDelete().Model((*EstateTargetModel)(nil)).Where("id = ?", id).Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	c, err := res.RowsAffected()
+
+	return int(c), err
+}
+
+// SaveValues stores values of windows not stored yet, ones of already stored windows are skipped;
+// Id of every given value is set to id of the stored value of its window, so observations can refer to it
+func SaveValues(ctx context.Context, connection bun.IDB, values []*EstateParsingValueModel) error {
 	if len(values) == 0 {
+		return nil
+	}
+
+	// ids are queried below, since skipped values are not returned
+	_, err := connection.NewInsert().
+		Model(&values).
+		On("CONFLICT DO NOTHING").
+		Returning("NULL").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	windows := make([][]any, 0, len(values))
+	for _, value := range values {
+		windows = append(windows, []any{value.TaskId, formatDate(value.DateStart), formatDate(value.DateEnd)})
+	}
+
+	var stored []*EstateParsingValueModel
+	err = connection.NewSelect().
+		Model(&stored).
+		Column("id", "task_id", "date_start", "date_end").
+		Where("(task_id, date_start, date_end) IN (?)", bun.In(windows)).
+		Scan(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[string]int, len(stored))
+	for _, value := range stored {
+		ids[windowKey(value.TaskId, value.DateStart, value.DateEnd)] = value.Id
+	}
+
+	for _, value := range values {
+		id, ok := ids[windowKey(value.TaskId, value.DateStart, value.DateEnd)]
+		if !ok {
+			return fmt.Errorf("value of task %d for %s - %s was not stored",
+				value.TaskId, formatDate(value.DateStart), formatDate(value.DateEnd))
+		}
+
+		value.Id = id
+	}
+
+	return nil
+}
+
+// SaveObservations stores observations, ones already stored for the run are skipped;
+// returns key columns of inserted observations
+func SaveObservations(ctx context.Context, connection bun.IDB, observations []*EstateParsingObservationModel) (inserted []*EstateParsingObservationModel, err error) {
+	if len(observations) == 0 {
+		return nil, nil
+	}
+
+	err = connection.NewInsert().
+		Model(&observations).
+		On("CONFLICT DO NOTHING").
+		Returning("id, value_id, run_id").
+		Scan(ctx, &inserted)
+
+	return inserted, err
+}
+
+func windowKey(taskId int, dateStart *time.Time, dateEnd *time.Time) string {
+	return fmt.Sprintf("%d/%s/%s", taskId, formatDate(dateStart), formatDate(dateEnd))
+}
+
+// formats date the way it gets into date column, times are written in utc
+func formatDate(date *time.Time) string {
+	return date.UTC().Format(time.DateOnly)
+}
+
+// SaveListings stores listings not stored for their window yet, ones already stored are skipped;
+// Id of every given listing is set to id of the stored listing, so observations can refer to it
+func SaveListings(ctx context.Context, connection bun.IDB, listings []*EstateListingModel) error {
+	if len(listings) == 0 {
+		return nil
+	}
+
+	// ids are queried below, since skipped listings are not returned
+	_, err := connection.NewInsert().
+		Model(&listings).
+		On("CONFLICT DO NOTHING").
+		Returning("NULL").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make([][]any, 0, len(listings))
+	for _, listing := range listings {
+		keys = append(keys, []any{listing.TaskId, formatDate(listing.DateStart), formatDate(listing.DateEnd), listing.AvitoId})
+	}
+
+	var stored []*EstateListingModel
+	err = connection.NewSelect().
+		Model(&stored).
+		Column("id", "task_id", "date_start", "date_end", "avito_id").
+		Where("(task_id, date_start, date_end, avito_id) IN (?)", bun.In(keys)).
+		Scan(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[string]int, len(stored))
+	for _, listing := range stored {
+		ids[listingKey(listing)] = listing.Id
+	}
+
+	for _, listing := range listings {
+		id, ok := ids[listingKey(listing)]
+		if !ok {
+			return fmt.Errorf("listing %d of task %d for %s - %s was not stored",
+				listing.AvitoId, listing.TaskId, formatDate(listing.DateStart), formatDate(listing.DateEnd))
+		}
+
+		listing.Id = id
+	}
+
+	return nil
+}
+
+func listingKey(listing *EstateListingModel) string {
+	return fmt.Sprintf("%s/%d", windowKey(listing.TaskId, listing.DateStart, listing.DateEnd), listing.AvitoId)
+}
+
+// SaveListingObservations stores links of listings to observations, ones already stored are skipped
+func SaveListingObservations(ctx context.Context, connection bun.IDB, observations []*EstateListingObservationModel) (affectedCount int, err error) {
+	if len(observations) == 0 {
 		return 0, nil
 	}
 
-	res, err := connection.NewInsert().Model(&values).On("CONFLICT DO NOTHING").Exec(ctx)
+	res, err := connection.NewInsert().Model(&observations).On("CONFLICT DO NOTHING").Returning("NULL").Exec(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -63,3 +282,141 @@ func SaveValues(ctx context.Context, connection bun.IDB, values []*EstateParsing
 
 	return int(c), err
 }
+
+// GetRunObservationWindows returns observations stored by run along with their date windows
+func GetRunObservationWindows(ctx context.Context, connection bun.IDB, runId string) (observations []*ObservationWindowModel, err error) {
+	err = connection.NewSelect().
+		TableExpr("avito_estate_parsing_observations AS aepo").
+		Join("JOIN avito_estate_parsing_values AS aepv ON aepv.id = aepo.value_id").
+		ColumnExpr("aepo.id AS observation_id").
+		ColumnExpr("aepv.task_id, aepv.date_start, aepv.date_end").
+		Where("aepo.run_id = ?", runId).
+		Scan(ctx, &observations)
+
+	return observations, err
+}
+
+func SaveFailures(ctx context.Context, connection bun.IDB, failures []*EstateParsingFailureModel) (affectedCount int, err error) {
+	if len(failures) == 0 {
+		return 0, nil
+	}
+
+	res, err := connection.NewInsert().Model(&failures).Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	c, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(c), err
+}
+
+func GetEnabledAnomalyRules(ctx context.Context, connection bun.IDB) (rules []*EstateAnomalyRuleModel, err error) {
+	err = connection.NewSelect().Model(&rules).Where("enabled").Order("id").Scan(ctx)
+
+	return rules, err
+}
+
+// GetObservationHistory returns up to limit latest observations of task for date windows of the same length,
+// observations of given run are left out, newest first and ones of unknown time last
+func GetObservationHistory(ctx context.Context, connection bun.IDB, taskId int, nights int, excludeRunId string, limit int) (observations []*EstateParsingObservationModel, err error) {
+	err = connection.NewSelect().
+		Model(&observations).
+		Join("JOIN avito_estate_parsing_values AS aepv ON aepv.id = aepo.value_id").
+		Where("aepv.task_id = ?", taskId).
+		Where("aepv.date_end - aepv.date_start = ?", nights).
+		Where("aepo.run_id IS DISTINCT FROM ?", excludeRunId).
+		OrderExpr("aepo.observed_at DESC NULLS LAST, aepo.id DESC").
+		Limit(limit).
+		Scan(ctx)
+
+	return observations, err
+}
+
+func SaveAnomalies(ctx context.Context, connection bun.IDB, anomalies []*EstateAnomalyModel) (affectedCount int, err error) {
+	if len(anomalies) == 0 {
+		return 0, nil
+	}
+
+	res, err := connection.NewInsert().Model(&anomalies).Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	c, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(c), err
+}
+
+// GetSelectors returns selector overrides, most recently updated first
+func GetSelectors(ctx context.Context, connection bun.IDB) (selectors []*EstateSelectorModel, err error) {
+	err = connection.NewSelect().Model(&selectors).Order("updated_at DESC", "name").Scan(ctx)
+
+	return selectors, err
+}
+
+func GetValueSeries(ctx context.Context, connection bun.IDB, filter ValueSeriesFilter) (values []*ValueSeriesModel, err error) {
+	query := connection.NewSelect().
+		TableExpr("avito_estate_parsing_observations AS aepo").
+		Join("JOIN avito_estate_parsing_values AS aepv ON aepv.id = aepo.value_id").
+		Join("JOIN avito_estate_parsing_tasks AS aept ON aept.id = aepv.task_id").
+		Join("JOIN avito_estate_locations AS ael ON ael.id = aept.avito_estate_location_id").
+		Join("JOIN avito_estate_targets AS aet ON aet.id = aept.avito_estate_target_id").
+		ColumnExpr("aepv.task_id").
+		ColumnExpr("ael.id AS location_id").
+		ColumnExpr("ael.name AS location_name").
+		ColumnExpr("aet.id AS target_id").
+		ColumnExpr("aet.name AS target_name").
+		ColumnExpr("aepv.date_start, aepv.date_end").
+		ColumnExpr("aepo.observed_at").
+		ColumnExpr("aepo.estate_total_count, aepo.estate_free_count").
+		ColumnExpr("CASE WHEN aepo.estate_total_count > 0 " +
+			"THEN 1 - aepo.estate_free_count::float8 / aepo.estate_total_count END AS occupancy_rate").
+		ColumnExpr("coalesce(aepo.suspect_reason, '') AS suspect_reason").
+		// observations of unknown time were stored before the rest
+		OrderExpr("aepv.task_id, aepv.date_start, aepv.date_end, aepo.observed_at NULLS FIRST, aepo.id")
+
+	if filter.LocationId != 0 {
+		query = query.Where("ael.id = ?", filter.LocationId)
+	}
+	if filter.TargetId != 0 {
+		query = query.Where("aet.id = ?", filter.TargetId)
+	}
+	if filter.TaskId != 0 {
+		query = query.Where("aepv.task_id = ?", filter.TaskId)
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("aepv.date_start >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		query = query.Where("aepv.date_end <= ?", *filter.DateTo)
+	}
+	if filter.ObservedFrom != nil {
+		query = query.Where("aepo.observed_at >= ?", *filter.ObservedFrom)
+	}
+	if filter.ObservedTo != nil {
+		query = query.Where("aepo.observed_at < ?", *filter.ObservedTo)
+	}
+
+	err = query.Scan(ctx, &values)
+
+	return values, err
+}
+
+func InsertRun(ctx context.Context, connection bun.IDB, run *EstateParsingRunModel) error {
+	_, err := connection.NewInsert().Model(run).Exec(ctx)
+
+	return err
+}
+
+func UpdateRun(ctx context.Context, connection bun.IDB, run *EstateParsingRunModel) error {
+	_, err := connection.NewUpdate().Model(run).WherePK().Exec(ctx)
+
+	return err
+}
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS avito_estate_parsing_values;
+
+--bun:split
+
+DROP TABLE IF EXISTS avito_estate_parsing_tasks;
+
+--bun:split
+
+DROP TABLE IF EXISTS avito_estate_targets;
+
+--bun:split
+
+DROP TABLE IF EXISTS avito_estate_locations;
@@ -0,0 +1,58 @@
+-- tables may already exist in databases created before migrations were introduced,
+-- so every statement is idempotent
+CREATE TABLE IF NOT EXISTS avito_estate_locations
+(
+    id       serial PRIMARY KEY,
+    name     text NOT NULL,
+    url_part text NOT NULL
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS avito_estate_targets
+(
+    id             serial PRIMARY KEY,
+    name           text NOT NULL,
+    url_part       text NOT NULL,
+    filter_text    text NOT NULL,
+    subfilter_text text
+);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS avito_estate_parsing_tasks
+(
+    id                       serial PRIMARY KEY,
+    avito_estate_location_id integer NOT NULL REFERENCES avito_estate_locations (id),
+    avito_estate_target_id   integer NOT NULL REFERENCES avito_estate_targets (id),
+    description              text    NOT NULL,
+    validate_title           text    NOT NULL
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS avito_estate_parsing_tasks_location_id_idx
+    ON avito_estate_parsing_tasks (avito_estate_location_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS avito_estate_parsing_tasks_target_id_idx
+    ON avito_estate_parsing_tasks (avito_estate_target_id);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS avito_estate_parsing_values
+(
+    id                 serial PRIMARY KEY,
+    task_id            integer NOT NULL REFERENCES avito_estate_parsing_tasks (id),
+    date_start         date    NOT NULL,
+    date_end           date    NOT NULL,
+    estate_total_count integer NOT NULL,
+    estate_free_count  integer NOT NULL
+);
+
+--bun:split
+
+-- SaveValues relies on this key to skip already collected windows with ON CONFLICT DO NOTHING
+CREATE UNIQUE INDEX IF NOT EXISTS avito_estate_parsing_values_task_id_date_start_date_end_key
+    ON avito_estate_parsing_values (task_id, date_start, date_end);
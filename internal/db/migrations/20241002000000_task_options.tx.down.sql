@@ -0,0 +1,5 @@
+ALTER TABLE avito_estate_parsing_tasks
+    DROP COLUMN IF EXISTS crawl_pages,
+    DROP COLUMN IF EXISTS crawl_page_limit,
+    DROP COLUMN IF EXISTS schedule,
+    DROP COLUMN IF EXISTS enabled;
@@ -0,0 +1,5 @@
+ALTER TABLE avito_estate_parsing_tasks
+    ADD COLUMN IF NOT EXISTS crawl_pages      boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS crawl_page_limit integer,
+    ADD COLUMN IF NOT EXISTS schedule         text,
+    ADD COLUMN IF NOT EXISTS enabled          boolean NOT NULL DEFAULT true;
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS avito_estate_parsing_values_created_at_idx;
+
+--bun:split
+
+ALTER TABLE avito_estate_parsing_values
+    DROP COLUMN IF EXISTS crawl_page_count,
+    DROP COLUMN IF EXISTS crawl_listing_count,
+    DROP COLUMN IF EXISTS crawl_count_mismatch,
+    DROP COLUMN IF EXISTS price_min,
+    DROP COLUMN IF EXISTS price_max,
+    DROP COLUMN IF EXISTS price_mean,
+    DROP COLUMN IF EXISTS price_median,
+    DROP COLUMN IF EXISTS price_p25,
+    DROP COLUMN IF EXISTS price_p75,
+    DROP COLUMN IF EXISTS price_sample_count,
+    DROP COLUMN IF EXISTS created_at;
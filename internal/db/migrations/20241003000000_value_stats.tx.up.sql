@@ -0,0 +1,23 @@
+ALTER TABLE avito_estate_parsing_values
+    ADD COLUMN IF NOT EXISTS crawl_page_count     integer,
+    ADD COLUMN IF NOT EXISTS crawl_listing_count  integer,
+    ADD COLUMN IF NOT EXISTS crawl_count_mismatch boolean,
+    ADD COLUMN IF NOT EXISTS price_min            integer,
+    ADD COLUMN IF NOT EXISTS price_max            integer,
+    ADD COLUMN IF NOT EXISTS price_mean           double precision,
+    ADD COLUMN IF NOT EXISTS price_median         double precision,
+    ADD COLUMN IF NOT EXISTS price_p25            double precision,
+    ADD COLUMN IF NOT EXISTS price_p75            double precision,
+    ADD COLUMN IF NOT EXISTS price_sample_count   integer     NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS created_at           timestamptz;
+
+--bun:split
+
+-- values collected before the column was added keep it null, when they were collected is unknown
+ALTER TABLE avito_estate_parsing_values
+    ALTER COLUMN created_at SET DEFAULT current_timestamp;
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS avito_estate_parsing_values_created_at_idx
+    ON avito_estate_parsing_values (created_at);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS avito_estate_listings;
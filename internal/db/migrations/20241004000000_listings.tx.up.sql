@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS avito_estate_listings
+(
+    id          serial PRIMARY KEY,
+    task_id     integer NOT NULL REFERENCES avito_estate_parsing_tasks (id),
+    date_start  date    NOT NULL,
+    date_end    date    NOT NULL,
+    avito_id    bigint  NOT NULL,
+    title       text    NOT NULL,
+    price       integer NOT NULL,
+    address     text    NOT NULL,
+    url         text    NOT NULL,
+    seller_type text    NOT NULL
+);
+
+--bun:split
+
+-- SaveListings relies on this key to skip listings already collected for the window
+CREATE UNIQUE INDEX IF NOT EXISTS avito_estate_listings_task_id_date_start_date_end_avito_id_key
+    ON avito_estate_listings (task_id, date_start, date_end, avito_id);
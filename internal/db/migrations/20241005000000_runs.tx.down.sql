@@ -0,0 +1,6 @@
+ALTER TABLE avito_estate_parsing_values
+    DROP COLUMN IF EXISTS run_id;
+
+--bun:split
+
+DROP TABLE IF EXISTS avito_estate_parsing_runs;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS avito_estate_parsing_runs
+(
+    id             uuid PRIMARY KEY,
+    started_at     timestamptz NOT NULL,
+    finished_at    timestamptz,
+    dry_run        boolean     NOT NULL,
+    task_count     integer     NOT NULL,
+    success_count  integer     NOT NULL,
+    failure_count  integer     NOT NULL,
+    parser_version text        NOT NULL,
+    host           text        NOT NULL
+);
+
+--bun:split
+
+ALTER TABLE avito_estate_parsing_values
+    ADD COLUMN IF NOT EXISTS run_id uuid REFERENCES avito_estate_parsing_runs (id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS avito_estate_parsing_values_run_id_idx
+    ON avito_estate_parsing_values (run_id);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS avito_estate_parsing_failures
+(
+    id            serial PRIMARY KEY,
+    run_id        uuid        NOT NULL REFERENCES avito_estate_parsing_runs (id),
+    task_id       integer     NOT NULL REFERENCES avito_estate_parsing_tasks (id),
+    date_start    date        NOT NULL,
+    date_end      date        NOT NULL,
+    attempt       integer     NOT NULL,
+    error_kind    text        NOT NULL,
+    selector      text,
+    url           text        NOT NULL,
+    message       text        NOT NULL,
+    snapshot_path text,
+    created_at    timestamptz NOT NULL DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS avito_estate_parsing_failures_task_id_idx
+    ON avito_estate_parsing_failures (task_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS avito_estate_parsing_failures_error_kind_idx
+    ON avito_estate_parsing_failures (error_kind);
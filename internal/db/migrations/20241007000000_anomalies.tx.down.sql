@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS avito_estate_anomalies;
+
+--bun:split
+
+DROP TABLE IF EXISTS avito_estate_anomaly_rules;
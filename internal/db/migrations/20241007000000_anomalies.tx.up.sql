@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS avito_estate_anomaly_rules
+(
+    id        serial PRIMARY KEY,
+    name      text             NOT NULL UNIQUE,
+    kind      text             NOT NULL CHECK (kind IN ('zscore', 'pct_change', 'bounds')),
+    metric    text             NOT NULL CHECK (metric IN ('total_count', 'free_count', 'occupancy_rate')),
+    threshold double precision,
+    min_value double precision,
+    max_value double precision,
+    -- number of previous values of the task rule compares against
+    history   integer          NOT NULL DEFAULT 30,
+    -- rule applies to every task when null
+    task_id   integer REFERENCES avito_estate_parsing_tasks (id),
+    enabled   boolean          NOT NULL DEFAULT true
+);
+
+--bun:split
+
+INSERT INTO avito_estate_anomaly_rules (name, kind, metric, threshold, min_value, max_value, history)
+SELECT name, kind, metric, threshold, min_value, max_value, history
+FROM (VALUES ('total_count_zscore', 'zscore', 'total_count', 3.0, NULL::float8, NULL::float8, 30),
+             ('free_count_zscore', 'zscore', 'free_count', 3.0, NULL, NULL, 30),
+             ('total_count_change', 'pct_change', 'total_count', 0.5, NULL, NULL, 1),
+             ('occupancy_rate_bounds', 'bounds', 'occupancy_rate', NULL, 0.01, 1.0, 0))
+         AS defaults (name, kind, metric, threshold, min_value, max_value, history)
+WHERE NOT EXISTS (SELECT 1 FROM avito_estate_anomaly_rules);
+
+--bun:split
+
+CREATE TABLE IF NOT EXISTS avito_estate_anomalies
+(
+    id         serial PRIMARY KEY,
+    run_id     uuid             NOT NULL REFERENCES avito_estate_parsing_runs (id),
+    task_id    integer          NOT NULL REFERENCES avito_estate_parsing_tasks (id),
+    date_start date             NOT NULL,
+    date_end   date             NOT NULL,
+    rule_id    integer          NOT NULL REFERENCES avito_estate_anomaly_rules (id),
+    rule_name  text             NOT NULL,
+    metric     text             NOT NULL,
+    value      double precision NOT NULL,
+    expected   double precision,
+    score      double precision,
+    message    text             NOT NULL,
+    created_at timestamptz      NOT NULL DEFAULT current_timestamp
+);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS avito_estate_anomalies_task_id_idx
+    ON avito_estate_anomalies (task_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS avito_estate_anomalies_run_id_idx
+    ON avito_estate_anomalies (run_id);
@@ -0,0 +1,8 @@
+-- overrides of built-in selectors, name is selector or template name as in selector package
+CREATE TABLE IF NOT EXISTS avito_estate_selectors
+(
+    name       text PRIMARY KEY,
+    value      text        NOT NULL,
+    version    text        NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT current_timestamp
+);
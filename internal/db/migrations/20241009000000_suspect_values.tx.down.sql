@@ -0,0 +1,2 @@
+ALTER TABLE avito_estate_parsing_values
+    DROP COLUMN IF EXISTS suspect_reason;
@@ -0,0 +1,2 @@
+ALTER TABLE avito_estate_parsing_values
+    ADD COLUMN IF NOT EXISTS suspect_reason text;
@@ -0,0 +1,7 @@
+ALTER TABLE avito_estate_parsing_failures
+    DROP COLUMN IF EXISTS proxy;
+
+--bun:split
+
+ALTER TABLE avito_estate_parsing_values
+    DROP COLUMN IF EXISTS proxy;
@@ -0,0 +1,7 @@
+ALTER TABLE avito_estate_parsing_values
+    ADD COLUMN IF NOT EXISTS proxy text;
+
+--bun:split
+
+ALTER TABLE avito_estate_parsing_failures
+    ADD COLUMN IF NOT EXISTS proxy text;
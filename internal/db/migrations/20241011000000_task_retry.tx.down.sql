@@ -0,0 +1,3 @@
+ALTER TABLE avito_estate_parsing_tasks
+    DROP COLUMN IF EXISTS retry_max_attempts,
+    DROP COLUMN IF EXISTS retry_base_delay_seconds;
@@ -0,0 +1,3 @@
+ALTER TABLE avito_estate_parsing_tasks
+    ADD COLUMN IF NOT EXISTS retry_max_attempts       integer,
+    ADD COLUMN IF NOT EXISTS retry_base_delay_seconds integer;
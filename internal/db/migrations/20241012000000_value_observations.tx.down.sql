@@ -0,0 +1,2 @@
+-- values are not touched, they keep the first observation of every window as before
+DROP TABLE IF EXISTS avito_estate_parsing_observations;
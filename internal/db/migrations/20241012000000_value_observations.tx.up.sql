@@ -0,0 +1,68 @@
+-- values keep the first observation of every window under (task_id, date_start, date_end) key,
+-- every run that parsed the window stores its own observation here, so windows form series over time
+CREATE TABLE IF NOT EXISTS avito_estate_parsing_observations
+(
+    id                   serial PRIMARY KEY,
+    value_id             integer NOT NULL REFERENCES avito_estate_parsing_values (id),
+    run_id               uuid REFERENCES avito_estate_parsing_runs (id),
+    observed_at          timestamptz DEFAULT current_timestamp,
+    estate_total_count   integer NOT NULL,
+    estate_free_count    integer NOT NULL,
+    crawl_page_count     integer,
+    crawl_listing_count  integer,
+    crawl_count_mismatch boolean,
+    price_min            integer,
+    price_max            integer,
+    price_mean           double precision,
+    price_median         double precision,
+    price_p25            double precision,
+    price_p75            double precision,
+    price_sample_count   integer NOT NULL DEFAULT 0,
+    suspect_reason       text,
+    proxy                text
+);
+
+--bun:split
+
+-- SaveObservations relies on this key to skip observations of run already stored with ON CONFLICT DO NOTHING
+CREATE UNIQUE INDEX IF NOT EXISTS avito_estate_parsing_observations_value_id_run_id_key
+    ON avito_estate_parsing_observations (value_id, run_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS avito_estate_parsing_observations_run_id_idx
+    ON avito_estate_parsing_observations (run_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS avito_estate_parsing_observations_observed_at_idx
+    ON avito_estate_parsing_observations (observed_at);
+
+--bun:split
+
+-- every value stored so far is the only observation of its window,
+-- run and collection time stay null for values stored before they were tracked
+INSERT INTO avito_estate_parsing_observations (value_id, run_id, observed_at,
+                                               estate_total_count, estate_free_count,
+                                               crawl_page_count, crawl_listing_count, crawl_count_mismatch,
+                                               price_min, price_max, price_mean, price_median, price_p25, price_p75,
+                                               price_sample_count, suspect_reason, proxy)
+SELECT v.id,
+       v.run_id,
+       v.created_at,
+       v.estate_total_count,
+       v.estate_free_count,
+       v.crawl_page_count,
+       v.crawl_listing_count,
+       v.crawl_count_mismatch,
+       v.price_min,
+       v.price_max,
+       v.price_mean,
+       v.price_median,
+       v.price_p25,
+       v.price_p75,
+       v.price_sample_count,
+       v.suspect_reason,
+       v.proxy
+FROM avito_estate_parsing_values AS v
+WHERE NOT EXISTS (SELECT FROM avito_estate_parsing_observations AS o WHERE o.value_id = v.id);
@@ -0,0 +1,2 @@
+ALTER TABLE avito_estate_parsing_runs
+    DROP COLUMN IF EXISTS skipped_count;
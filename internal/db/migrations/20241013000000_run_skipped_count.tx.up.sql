@@ -0,0 +1,3 @@
+-- tasks left unattempted after the run was aborted or cancelled are neither succeeded nor failed
+ALTER TABLE avito_estate_parsing_runs
+    ADD COLUMN IF NOT EXISTS skipped_count integer NOT NULL DEFAULT 0;
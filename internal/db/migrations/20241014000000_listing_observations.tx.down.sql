@@ -0,0 +1,2 @@
+-- listings are not touched, they keep the first sighting of every offer as before
+DROP TABLE IF EXISTS avito_estate_listing_observations;
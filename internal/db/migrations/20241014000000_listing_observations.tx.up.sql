@@ -0,0 +1,35 @@
+-- listings keep the first sighting of offer in window under (task_id, date_start, date_end, avito_id) key,
+-- every observation the offer was seen in is linked to it here along with its price at that time
+CREATE TABLE IF NOT EXISTS avito_estate_listing_observations
+(
+    id             serial PRIMARY KEY,
+    listing_id     integer NOT NULL REFERENCES avito_estate_listings (id),
+    observation_id integer NOT NULL REFERENCES avito_estate_parsing_observations (id),
+    price          integer NOT NULL
+);
+
+--bun:split
+
+-- SaveListingObservations relies on this key to skip listings of observation already stored
+CREATE UNIQUE INDEX IF NOT EXISTS avito_estate_listing_observations_listing_id_observation_id_key
+    ON avito_estate_listing_observations (listing_id, observation_id);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS avito_estate_listing_observations_observation_id_idx
+    ON avito_estate_listing_observations (observation_id);
+
+--bun:split
+
+-- listings stored so far were collected along with the first observation of their window
+INSERT INTO avito_estate_listing_observations (listing_id, observation_id, price)
+SELECT l.id, o.id, l.price
+FROM avito_estate_listings AS l
+         JOIN avito_estate_parsing_values AS v
+              ON v.task_id = l.task_id AND v.date_start = l.date_start AND v.date_end = l.date_end
+         JOIN LATERAL (SELECT id
+                       FROM avito_estate_parsing_observations
+                       WHERE value_id = v.id
+                       ORDER BY id
+                       LIMIT 1) AS o ON true
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"embed"
+	"github.com/uptrace/bun/migrate"
+)
+
+// sql migrations are named <timestamp>_<name>.tx.(up|down).sql,
+// statements are separated by --bun:split and run in a transaction
+//
+//go:embed *.sql
+var sqlMigrations embed.FS
+
+var Migrations = migrate.NewMigrations()
+
+func init() {
+	if err := Migrations.Discover(sqlMigrations); err != nil {
+		panic(err)
+	}
+}
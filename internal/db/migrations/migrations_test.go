@@ -0,0 +1,268 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+	"github.com/uptrace/bun/migrate"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d{14})_([a-z0-9_]+)\.tx\.(up|down)\.sql$`)
+
+func TestMigrationFiles(t *testing.T) {
+	files, err := fs.Glob(sqlMigrations, "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sides := make(map[string][]string)
+	for _, file := range files {
+		m := migrationFileName.FindStringSubmatch(file)
+		if m == nil {
+			t.Errorf("%s: expected name <timestamp>_<name>.tx.(up|down).sql", file)
+			continue
+		}
+
+		name := m[1] + "_" + m[2]
+		sides[name] = append(sides[name], m[3])
+	}
+
+	for name, s := range sides {
+		if len(s) != 2 {
+			t.Errorf("%s: expected up and down migration, got %v", name, s)
+		}
+	}
+
+	if got := len(Migrations.Sorted()); got != len(sides) {
+		t.Errorf("expected %d discovered migrations, got %d", len(sides), got)
+	}
+}
+
+func TestMigrationStatements(t *testing.T) {
+	// statements must be safe to run against databases created before migrations were introduced,
+	// and rolling back must not lose rows of tables that stay
+	tests := []struct {
+		name    string
+		pattern *regexp.Regexp
+		isDown  bool
+	}{
+		{name: "create without if not exists", pattern: regexp.MustCompile(`(?i)\bCREATE\s+(UNIQUE\s+)?(TABLE|INDEX)\s+(?:[^I]|I[^F])`)},
+		{name: "add column without if not exists", pattern: regexp.MustCompile(`(?i)\bADD\s+COLUMN\s+(?:[^I]|I[^F])`)},
+		{name: "drop without if exists", pattern: regexp.MustCompile(`(?i)\bDROP\s+(TABLE|INDEX|COLUMN)\s+(?:[^I]|I[^F])`)},
+		{name: "rows deleted on rollback", pattern: regexp.MustCompile(`(?i)\b(DELETE|TRUNCATE)\b`), isDown: true},
+	}
+
+	files, err := fs.Glob(sqlMigrations, "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, file := range files {
+				if tt.isDown && !strings.HasSuffix(file, ".down.sql") {
+					continue
+				}
+
+				content, err := fs.ReadFile(sqlMigrations, file)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, statement := range strings.Split(string(content), "--bun:split") {
+					statement = withoutComments(statement)
+					if tt.pattern.MatchString(statement) {
+						t.Errorf("%s: %s", file, strings.TrimSpace(statement))
+					}
+				}
+			}
+		})
+	}
+}
+
+func withoutComments(statement string) string {
+	lines := strings.Split(statement, "\n")
+	for i, line := range lines {
+		if j := strings.Index(line, "--"); j >= 0 {
+			lines[i] = line[:j]
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// TestMigrateLegacyData runs migrations against postgres given by TEST_DB_CONNECTION_STRING,
+// every run uses its own schema which is dropped afterwards
+func TestMigrateLegacyData(t *testing.T) {
+	dsn := os.Getenv("TEST_DB_CONNECTION_STRING")
+	if dsn == "" {
+		t.Skip("TEST_DB_CONNECTION_STRING is not set")
+	}
+
+	ctx := context.Background()
+	connection := testConnection(t, dsn)
+
+	// database as it was before value observations were introduced
+	legacy := migrator(t, connection, "20241012000000")
+	if _, err := legacy.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	exec(t, connection, `INSERT INTO avito_estate_locations (id, name, url_part) VALUES (1, 'Сургут', 'surgut')`)
+	exec(t, connection, `INSERT INTO avito_estate_targets (id, name, url_part, filter_text) VALUES (1, 'Квартиры', 'kvartiry', 'Квартиры')`)
+	exec(t, connection, `INSERT INTO avito_estate_parsing_tasks (id, avito_estate_location_id, avito_estate_target_id, description, validate_title)
+		VALUES (1, 1, 1, 'test', 'test')`)
+	exec(t, connection, `INSERT INTO avito_estate_parsing_runs (id, started_at, dry_run, task_count, success_count, failure_count, parser_version, host)
+		VALUES ('7d7f1e0c-8f5e-4a43-9d2c-3c1b8e5b9a01', now(), false, 1, 1, 0, 'test', 'test')`)
+	// value stored before runs and collection time were tracked
+	exec(t, connection, `INSERT INTO avito_estate_parsing_values (task_id, date_start, date_end, estate_total_count, estate_free_count, created_at)
+		VALUES (1, '2026-11-10', '2026-11-12', 120, 30, NULL)`)
+	exec(t, connection, `INSERT INTO avito_estate_parsing_values (task_id, date_start, date_end, estate_total_count, estate_free_count, run_id)
+		VALUES (1, '2026-11-11', '2026-11-13', 120, 40, '7d7f1e0c-8f5e-4a43-9d2c-3c1b8e5b9a01')`)
+	exec(t, connection, `INSERT INTO avito_estate_listings (task_id, date_start, date_end, avito_id, title, price, address, url, seller_type)
+		VALUES (1, '2026-11-10', '2026-11-12', 4012345678, 'test', 2500, 'test', 'test', 'test')`)
+
+	latest := migrator(t, connection, "")
+	if _, err := latest.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// collection time of legacy value stays unknown instead of being made up
+	if got := count(t, connection, `SELECT count(*) FROM avito_estate_parsing_values WHERE created_at IS NULL`); got != 1 {
+		t.Errorf("expected 1 value without created_at, got %d", got)
+	}
+
+	// every stored value becomes the only observation of its window
+	if got := count(t, connection, `SELECT count(*) FROM avito_estate_parsing_observations`); got != 2 {
+		t.Errorf("expected 2 observations, got %d", got)
+	}
+	if got := count(t, connection, `SELECT count(*) FROM avito_estate_parsing_observations
+		WHERE observed_at IS NULL AND run_id IS NULL AND estate_free_count = 30`); got != 1 {
+		t.Errorf("expected legacy observation without time and run, got %d", got)
+	}
+
+	// listing is linked to the observation it was collected with
+	if got := count(t, connection, `SELECT count(*) FROM avito_estate_listing_observations AS aelo
+		JOIN avito_estate_parsing_observations AS aepo ON aepo.id = aelo.observation_id
+		WHERE aepo.estate_free_count = 30 AND aelo.price = 2500`); got != 1 {
+		t.Errorf("expected legacy listing to be linked to observation of its window, got %d links", got)
+	}
+
+	// window key of values is kept
+	exec(t, connection, `INSERT INTO avito_estate_parsing_values (task_id, date_start, date_end, estate_total_count, estate_free_count)
+		VALUES (1, '2026-11-10', '2026-11-12', 120, 35) ON CONFLICT DO NOTHING`)
+	if got := count(t, connection, `SELECT count(*) FROM avito_estate_parsing_values`); got != 2 {
+		t.Errorf("expected window to be stored once, got %d values", got)
+	}
+
+	// rollback drops observations, but keeps every value and listing
+	if _, err := latest.Rollback(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(t, connection, `SELECT count(*) FROM avito_estate_parsing_values`); got != 2 {
+		t.Errorf("expected values to be kept on rollback, got %d", got)
+	}
+	if got := count(t, connection, `SELECT count(*) FROM avito_estate_listings`); got != 1 {
+		t.Errorf("expected listings to be kept on rollback, got %d", got)
+	}
+	if got := count(t, connection, `SELECT count(*) FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_name = 'avito_estate_parsing_observations'`); got != 0 {
+		t.Error("expected observations table to be dropped on rollback")
+	}
+
+	// migrations can be applied again after rollback
+	if _, err := latest.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testConnection(t *testing.T, dsn string) *bun.DB {
+	t.Helper()
+
+	schema := fmt.Sprintf("test_migrations_%d", time.Now().UnixNano())
+
+	admin := bun.NewDB(sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn))), pgdialect.New())
+	t.Cleanup(func() {
+		_ = admin.Close()
+	})
+	exec(t, admin, "CREATE SCHEMA "+schema)
+	t.Cleanup(func() {
+		_, _ = admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+	})
+
+	connection := bun.NewDB(sql.OpenDB(pgdriver.NewConnector(
+		pgdriver.WithDSN(dsn),
+		pgdriver.WithConnParams(map[string]interface{}{"search_path": schema}),
+	)), pgdialect.New())
+	t.Cleanup(func() {
+		_ = connection.Close()
+	})
+
+	return connection
+}
+
+// returns migrator of embedded migrations older than given timestamp, of every one when it is empty
+func migrator(t *testing.T, connection *bun.DB, before string) *migrate.Migrator {
+	t.Helper()
+
+	files, err := fs.Glob(sqlMigrations, "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := fstest.MapFS{}
+	for _, file := range files {
+		if before != "" && file >= before {
+			continue
+		}
+
+		content, err := fs.ReadFile(sqlMigrations, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fsys[file] = &fstest.MapFile{Data: content}
+	}
+
+	migrations := migrate.NewMigrations()
+	if err = migrations.Discover(fsys); err != nil {
+		t.Fatal(err)
+	}
+
+	m := migrate.NewMigrator(connection, migrations,
+		migrate.WithTableName("avito_estate_migrations"),
+		migrate.WithLocksTableName("avito_estate_migration_locks"))
+	if err = m.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func exec(t *testing.T, connection *bun.DB, query string) {
+	t.Helper()
+
+	if _, err := connection.Exec(query); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+func count(t *testing.T, connection *bun.DB, query string) int {
+	t.Helper()
+
+	var n int
+	if err := connection.QueryRow(query).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+
+	return n
+}
@@ -7,35 +7,216 @@ import (
 
 type EstateLocationModel struct {
 	bun.BaseModel `bun:"table:avito_estate_locations,alias:ael"`
-	Id            int    `bun:"id,pk,autoincrement"`
-	Name          string `bun:"name,notnull"`
-	UrlPart       string `bun:"url_part,notnull"`
+	Id            int    `bun:"id,pk,autoincrement" json:"id"`
+	Name          string `bun:"name,notnull" json:"name"`
+	UrlPart       string `bun:"url_part,notnull" json:"url_part"`
 }
 
 type EstateTargetModel struct {
 	bun.BaseModel `bun:"table:avito_estate_targets,alias:aet"`
-	Id            int    `bun:"id,pk,autoincrement"`
-	Name          string `bun:"name,notnull"`
-	UrlPart       string `bun:"url_part,notnull"`
-	FilterText    string `bun:"filter_text,notnull"`
-	SubfilterText string `bun:"subfilter_text"`
+	Id            int    `bun:"id,pk,autoincrement" json:"id"`
+	Name          string `bun:"name,notnull" json:"name"`
+	UrlPart       string `bun:"url_part,notnull" json:"url_part"`
+	FilterText    string `bun:"filter_text,notnull" json:"filter_text"`
+	SubfilterText string `bun:"subfilter_text" json:"subfilter_text"`
 }
 
 type EstateParsingTaskModel struct {
 	bun.BaseModel    `bun:"table:avito_estate_parsing_tasks,alias:aept"`
-	Id               int    `bun:"id,pk,autoincrement"`
-	EstateLocationId int    `bun:"avito_estate_location_id,notnull"`
-	EstateTargetId   int    `bun:"avito_estate_target_id,notnull"`
-	Description      string `bun:"description,notnull"`
-	ValidateTitle    string `bun:"validate_title,notnull"`
+	Id               int    `bun:"id,pk,autoincrement" json:"id"`
+	EstateLocationId int    `bun:"avito_estate_location_id,notnull" json:"location_id"`
+	EstateTargetId   int    `bun:"avito_estate_target_id,notnull" json:"target_id"`
+	Description      string `bun:"description,notnull" json:"description"`
+	ValidateTitle    string `bun:"validate_title,notnull" json:"validate_title"`
+	CrawlPages       bool   `bun:"crawl_pages,notnull,default:false" json:"crawl_pages"`
+	CrawlPageLimit   int    `bun:"crawl_page_limit,nullzero" json:"crawl_page_limit,omitempty"`
+	Enabled          bool   `bun:"enabled,notnull,default:true" json:"enabled"`
+	// cron expression overriding global schedule in daemon mode
+	Schedule string `bun:"schedule,nullzero" json:"schedule,omitempty"`
+	// retry settings overriding global retry policy when set
+	RetryMaxAttempts      int `bun:"retry_max_attempts,nullzero" json:"retry_max_attempts,omitempty"`
+	RetryBaseDelaySeconds int `bun:"retry_base_delay_seconds,nullzero" json:"retry_base_delay_seconds,omitempty"`
 }
 
+// EstateParsingValueModel is the first observation of task date window,
+// later runs of the window are stored as EstateParsingObservationModel
 type EstateParsingValueModel struct {
-	bun.BaseModel    `bun:"table:avito_estate_parsing_values,alias:aepv"`
-	Id               int        `bun:"id,pk,autoincrement"`
-	TaskId           int        `bun:"task_id,notnull"`
-	DateStart        *time.Time `bun:"date_start,type:date,notnull"`
-	DateEnd          *time.Time `bun:"date_end,type:date,notnull"`
-	EstateTotalCount int        `bun:"estate_total_count,notnull"`
-	EstateFreeCount  int        `bun:"estate_free_count,notnull"`
+	bun.BaseModel `bun:"table:avito_estate_parsing_values,alias:aepv"`
+	Id            int        `bun:"id,pk,autoincrement"`
+	TaskId        int        `bun:"task_id,notnull"`
+	DateStart     *time.Time `bun:"date_start,type:date,notnull"`
+	DateEnd       *time.Time `bun:"date_end,type:date,notnull"`
+	EstateParsingStatsModel
+	// CreatedAt is zero for values stored before it was tracked
+	CreatedAt time.Time `bun:"created_at,nullzero,default:current_timestamp"`
+	RunId     string    `bun:"run_id,type:uuid,nullzero"`
+}
+
+// EstateParsingObservationModel is value of task date window collected by single run
+type EstateParsingObservationModel struct {
+	bun.BaseModel `bun:"table:avito_estate_parsing_observations,alias:aepo"`
+	Id            int `bun:"id,pk,autoincrement"`
+	ValueId       int `bun:"value_id,notnull"`
+	EstateParsingStatsModel
+	// ObservedAt and RunId are zero for observations stored before they were tracked
+	ObservedAt time.Time `bun:"observed_at,nullzero,default:current_timestamp"`
+	RunId      string    `bun:"run_id,type:uuid,nullzero"`
+}
+
+// EstateParsingStatsModel holds columns shared by value and its observations
+type EstateParsingStatsModel struct {
+	EstateTotalCount   int      `bun:"estate_total_count,notnull"`
+	EstateFreeCount    int      `bun:"estate_free_count,notnull"`
+	CrawlPageCount     *int     `bun:"crawl_page_count"`
+	CrawlListingCount  *int     `bun:"crawl_listing_count"`
+	CrawlCountMismatch *bool    `bun:"crawl_count_mismatch"`
+	PriceMin           *int     `bun:"price_min"`
+	PriceMax           *int     `bun:"price_max"`
+	PriceMean          *float64 `bun:"price_mean"`
+	PriceMedian        *float64 `bun:"price_median"`
+	PriceP25           *float64 `bun:"price_p25"`
+	PriceP75           *float64 `bun:"price_p75"`
+	PriceSampleCount   int      `bun:"price_sample_count,notnull,default:0"`
+	SuspectReason      string   `bun:"suspect_reason,nullzero"`
+	Proxy              string   `bun:"proxy,nullzero"`
+}
+
+type EstateParsingRunModel struct {
+	bun.BaseModel `bun:"table:avito_estate_parsing_runs,alias:aepr"`
+	// Id is TraceId of the run, so its logs can be found by it
+	Id           string     `bun:"id,pk,type:uuid"`
+	StartedAt    time.Time  `bun:"started_at,notnull"`
+	FinishedAt   *time.Time `bun:"finished_at"`
+	DryRun       bool       `bun:"dry_run,notnull"`
+	TaskCount    int        `bun:"task_count,notnull"`
+	SuccessCount int        `bun:"success_count,notnull"`
+	FailureCount int        `bun:"failure_count,notnull"`
+	// SkippedCount is number of tasks never attempted, eg. after the run was aborted
+	SkippedCount  int    `bun:"skipped_count,notnull"`
+	ParserVersion string `bun:"parser_version,notnull"`
+	Host          string `bun:"host,notnull"`
+}
+
+// EstateListingModel is the first sighting of offer in task date window,
+// every observation it was seen in is stored as EstateListingObservationModel
+type EstateListingModel struct {
+	bun.BaseModel `bun:"table:avito_estate_listings,alias:aels"`
+	Id            int        `bun:"id,pk,autoincrement"`
+	TaskId        int        `bun:"task_id,notnull"`
+	DateStart     *time.Time `bun:"date_start,type:date,notnull"`
+	DateEnd       *time.Time `bun:"date_end,type:date,notnull"`
+	AvitoId       int64      `bun:"avito_id,notnull"`
+	Title         string     `bun:"title,notnull"`
+	Price         int        `bun:"price,notnull"`
+	Address       string     `bun:"address,notnull"`
+	Url           string     `bun:"url,notnull"`
+	SellerType    string     `bun:"seller_type,notnull"`
+}
+
+// EstateListingObservationModel links listing to observation of its window it was seen in
+type EstateListingObservationModel struct {
+	bun.BaseModel `bun:"table:avito_estate_listing_observations,alias:aelo"`
+	Id            int `bun:"id,pk,autoincrement"`
+	ListingId     int `bun:"listing_id,notnull"`
+	ObservationId int `bun:"observation_id,notnull"`
+	// Price is nightly price at the time of observation
+	Price int `bun:"price,notnull"`
+}
+
+// EstateParsingFailureModel is a single failed attempt of parsing task
+type EstateParsingFailureModel struct {
+	bun.BaseModel `bun:"table:avito_estate_parsing_failures,alias:aepf"`
+	Id            int        `bun:"id,pk,autoincrement"`
+	RunId         string     `bun:"run_id,type:uuid,notnull"`
+	TaskId        int        `bun:"task_id,notnull"`
+	DateStart     *time.Time `bun:"date_start,type:date,notnull"`
+	DateEnd       *time.Time `bun:"date_end,type:date,notnull"`
+	Attempt       int        `bun:"attempt,notnull"`
+	ErrorKind     string     `bun:"error_kind,notnull"`
+	Selector      string     `bun:"selector,nullzero"`
+	Url           string     `bun:"url,notnull"`
+	Message       string     `bun:"message,notnull"`
+	SnapshotPath  string     `bun:"snapshot_path,nullzero"`
+	Proxy         string     `bun:"proxy,nullzero"`
+	CreatedAt     time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// EstateAnomalyRuleModel configures check of new parsing values against task history
+type EstateAnomalyRuleModel struct {
+	bun.BaseModel `bun:"table:avito_estate_anomaly_rules,alias:aear"`
+	Id            int      `bun:"id,pk,autoincrement"`
+	Name          string   `bun:"name,notnull"`
+	Kind          string   `bun:"kind,notnull"`
+	Metric        string   `bun:"metric,notnull"`
+	Threshold     *float64 `bun:"threshold"`
+	MinValue      *float64 `bun:"min_value"`
+	MaxValue      *float64 `bun:"max_value"`
+	History       int      `bun:"history,notnull"`
+	// rule applies to every task when zero
+	TaskId  int  `bun:"task_id,nullzero"`
+	Enabled bool `bun:"enabled,notnull,default:true"`
+}
+
+type EstateAnomalyModel struct {
+	bun.BaseModel `bun:"table:avito_estate_anomalies,alias:aea"`
+	Id            int        `bun:"id,pk,autoincrement"`
+	RunId         string     `bun:"run_id,type:uuid,notnull"`
+	TaskId        int        `bun:"task_id,notnull"`
+	DateStart     *time.Time `bun:"date_start,type:date,notnull"`
+	DateEnd       *time.Time `bun:"date_end,type:date,notnull"`
+	RuleId        int        `bun:"rule_id,notnull"`
+	RuleName      string     `bun:"rule_name,notnull"`
+	Metric        string     `bun:"metric,notnull"`
+	Value         float64    `bun:"value,notnull"`
+	Expected      *float64   `bun:"expected"`
+	Score         *float64   `bun:"score"`
+	Message       string     `bun:"message,notnull"`
+	CreatedAt     time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// EstateSelectorModel overrides built-in selector or selector template with the same name
+type EstateSelectorModel struct {
+	bun.BaseModel `bun:"table:avito_estate_selectors,alias:aes"`
+	Name          string    `bun:"name,pk"`
+	Value         string    `bun:"value,notnull"`
+	Version       string    `bun:"version,notnull"`
+	UpdatedAt     time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// ObservationWindowModel is observation joined with date window of its value
+type ObservationWindowModel struct {
+	ObservationId int        `bun:"observation_id"`
+	TaskId        int        `bun:"task_id"`
+	DateStart     *time.Time `bun:"date_start"`
+	DateEnd       *time.Time `bun:"date_end"`
+}
+
+// ValueSeriesModel is observation of parsing value joined with its task, location and target
+type ValueSeriesModel struct {
+	TaskId       int       `bun:"task_id" json:"task_id"`
+	LocationId   int       `bun:"location_id" json:"location_id"`
+	LocationName string    `bun:"location_name" json:"location_name"`
+	TargetId     int       `bun:"target_id" json:"target_id"`
+	TargetName   string    `bun:"target_name" json:"target_name"`
+	DateStart    time.Time `bun:"date_start" json:"date_start"`
+	DateEnd      time.Time `bun:"date_end" json:"date_end"`
+	// ObservedAt is nil for values stored before it was tracked
+	ObservedAt       *time.Time `bun:"observed_at" json:"observed_at"`
+	EstateTotalCount int        `bun:"estate_total_count" json:"total_count"`
+	EstateFreeCount  int        `bun:"estate_free_count" json:"free_count"`
+	OccupancyRate    *float64   `bun:"occupancy_rate" json:"occupancy_rate"`
+	SuspectReason    string     `bun:"suspect_reason" json:"suspect_reason,omitempty"`
+}
+
+// ValueSeriesFilter narrows value series, zero fields are not applied
+type ValueSeriesFilter struct {
+	LocationId int
+	TargetId   int
+	TaskId     int
+	// DateFrom and DateTo bound date windows: date_start >= DateFrom and date_end <= DateTo
+	DateFrom *time.Time
+	DateTo   *time.Time
+	// ObservedFrom and ObservedTo bound when value was collected, ObservedTo is exclusive
+	ObservedFrom *time.Time
+	ObservedTo   *time.Time
 }
@@ -0,0 +1,74 @@
+package driver
+
+import (
+	"errors"
+	"github.com/csr-ugra/avito-estate-parser/internal/selector"
+	"net/url"
+)
+
+// ErrElementNotFound is returned when no element matches selector
+var ErrElementNotFound = errors.New("element not found")
+
+// ErrNavigationTimeout is returned when page did not load in time
+var ErrNavigationTimeout = errors.New("navigation timed out")
+
+type Key string
+
+const (
+	KeyEscape Key = "Escape"
+)
+
+// Browser opens pages, implemented by live browser over devtools and by saved html fixtures
+type Browser interface {
+	NewPage() (Page, error)
+	// NewContext opens isolated incognito context over the same browser connection
+	NewContext(opts ContextOptions) (BrowserContext, error)
+}
+
+// ContextOptions tune browser context, zero value gives plain incognito context
+type ContextOptions struct {
+	// Proxy routes traffic of context, http, https and socks5 proxies are supported,
+	// credentials are only supported for http ones since chrome can't authenticate to socks5,
+	// socks5 proxy with credentials is put behind proxy.Forwarder first
+	Proxy *url.URL
+	// Stealth hides automation traces from page scripts, gives context random user agent
+	// and viewport, and makes clicks and typing look like those of a person
+	Stealth bool
+}
+
+type BrowserContext interface {
+	Browser
+	Close() error
+}
+
+type Page interface {
+	Navigate(url string) error
+	// WaitNavigation must be called before action that triggers navigation,
+	// returned function blocks until page is loaded and network is idle
+	WaitNavigation() func() error
+	// Element returns first element matching selector without waiting for it to appear
+	Element(sel selector.Selector) (Element, error)
+	// Elements returns every element matching selector, empty when nothing matches
+	Elements(sel selector.Selector) ([]Element, error)
+	Press(key Key) error
+	Close() error
+
+	URL() (string, error)
+	HTML() (string, error)
+	// Screenshot returns full page png image
+	Screenshot() ([]byte, error)
+	// Console returns browser console messages, script exceptions
+	// and network errors collected since page was opened
+	Console() []string
+}
+
+type Element interface {
+	Click() error
+	Text() (string, error)
+	// Attribute returns nil when element does not have attribute
+	Attribute(name string) (*string, error)
+	// Elements returns every descendant matching selector
+	Elements(sel selector.Selector) ([]Element, error)
+	// Input replaces element value with text
+	Input(text string) error
+}
@@ -0,0 +1,205 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/csr-ugra/avito-estate-parser/internal/selector"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const fixtureManifestName = "fixtures.json"
+
+// Fixtures describe saved html pages and transitions between them,
+// loaded from fixtures.json in fixture directory, eg.
+//
+//	{
+//	  "pages": {"https://www.avito.ru/surgut/kvartiry/sdam/posutochno": "list.html"},
+//	  "clicks": {"list.html": {"button[data-marker=\"search-filters/submit-button\"]": "list_filtered.html"}}
+//	}
+type Fixtures struct {
+	Dir string `json:"-"`
+	// Pages maps url to html file opened on navigation
+	Pages map[string]string `json:"pages"`
+	// Clicks maps html file to selectors of elements which replace
+	// current page with another html file when clicked
+	Clicks map[string]map[string]string `json:"clicks"`
+}
+
+func LoadFixtures(dir string) (*Fixtures, error) {
+	data, err := os.ReadFile(filepath.Join(dir, fixtureManifestName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture manifest: %v", err)
+	}
+
+	fixtures := &Fixtures{Dir: dir}
+	if err = json.Unmarshal(data, fixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture manifest: %v", err)
+	}
+
+	return fixtures, nil
+}
+
+type fixtureBrowser struct {
+	fixtures *Fixtures
+}
+
+func NewFixtureBrowser(fixtures *Fixtures) Browser {
+	return &fixtureBrowser{fixtures: fixtures}
+}
+
+func (b *fixtureBrowser) NewPage() (Page, error) {
+	return &fixturePage{fixtures: b.fixtures}, nil
+}
+
+// NewContext returns browser itself since fixture pages share no state, options are ignored
+func (b *fixtureBrowser) NewContext(_ ContextOptions) (BrowserContext, error) {
+	return b, nil
+}
+
+func (b *fixtureBrowser) Close() error {
+	return nil
+}
+
+type fixturePage struct {
+	fixtures *Fixtures
+	url      string
+	file     string
+	document *goquery.Document
+}
+
+func (p *fixturePage) Navigate(url string) error {
+	file, ok := p.fixtures.Pages[url]
+	if !ok {
+		return fmt.Errorf("no fixture for url %s", url)
+	}
+
+	p.url = url
+
+	return p.load(file)
+}
+
+func (p *fixturePage) load(file string) error {
+	f, err := os.Open(filepath.Join(p.fixtures.Dir, file))
+	if err != nil {
+		return err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	document, err := goquery.NewDocumentFromReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to parse fixture %s: %v", file, err)
+	}
+
+	p.file = file
+	p.document = document
+
+	return nil
+}
+
+// WaitNavigation returns immediately since fixture pages are switched synchronously
+func (p *fixturePage) WaitNavigation() func() error {
+	return func() error { return nil }
+}
+
+func (p *fixturePage) Element(sel selector.Selector) (Element, error) {
+	elements, err := p.Elements(sel)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(elements) == 0 {
+		return nil, ErrElementNotFound
+	}
+
+	return elements[0], nil
+}
+
+func (p *fixturePage) Elements(sel selector.Selector) ([]Element, error) {
+	if p.document == nil {
+		return nil, fmt.Errorf("no fixture page loaded")
+	}
+
+	return p.wrap(p.document.Find(sel.String())), nil
+}
+
+func (p *fixturePage) Press(Key) error {
+	return nil
+}
+
+func (p *fixturePage) Close() error {
+	p.document = nil
+	return nil
+}
+
+func (p *fixturePage) URL() (string, error) {
+	return p.url, nil
+}
+
+func (p *fixturePage) HTML() (string, error) {
+	if p.document == nil {
+		return "", fmt.Errorf("no fixture page loaded")
+	}
+
+	return goquery.OuterHtml(p.document.Selection)
+}
+
+// Screenshot is not supported for fixtures since pages are never rendered
+func (p *fixturePage) Screenshot() ([]byte, error) {
+	return nil, fmt.Errorf("screenshot is not supported for fixture %s", p.file)
+}
+
+func (p *fixturePage) Console() []string {
+	return nil
+}
+
+func (p *fixturePage) wrap(selection *goquery.Selection) []Element {
+	elements := make([]Element, 0, selection.Length())
+	selection.Each(func(_ int, s *goquery.Selection) {
+		elements = append(elements, &fixtureElement{page: p, selection: s})
+	})
+
+	return elements
+}
+
+type fixtureElement struct {
+	page      *fixturePage
+	selection *goquery.Selection
+}
+
+// Click switches page to the file configured for clicked element, clicks on other elements do nothing
+func (e *fixtureElement) Click() error {
+	for sel, file := range e.page.fixtures.Clicks[e.page.file] {
+		if e.page.document.Find(sel).IsSelection(e.selection) {
+			return e.page.load(file)
+		}
+	}
+
+	return nil
+}
+
+func (e *fixtureElement) Text() (string, error) {
+	return strings.TrimSpace(e.selection.Text()), nil
+}
+
+func (e *fixtureElement) Attribute(name string) (*string, error) {
+	value, ok := e.selection.Attr(name)
+	if !ok {
+		return nil, nil
+	}
+
+	return &value, nil
+}
+
+func (e *fixtureElement) Elements(sel selector.Selector) ([]Element, error) {
+	return e.page.wrap(e.selection.Find(sel.String())), nil
+}
+
+func (e *fixtureElement) Input(text string) error {
+	e.selection.SetAttr("value", text)
+	return nil
+}
@@ -0,0 +1,313 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/selector"
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var rodKeys = map[Key]input.Key{
+	KeyEscape: input.Escape,
+}
+
+type rodBrowser struct {
+	browser           *rod.Browser
+	navigationTimeout time.Duration
+	// proxyAuth is credentials of context proxy, pages answer proxy auth challenges with it
+	proxyAuth *url.Userinfo
+	// stealth is nil unless context is opened in stealth mode
+	stealth *stealthProfile
+}
+
+// NewRodBrowser wraps connected browser, navigation waits forever when navigationTimeout is zero
+func NewRodBrowser(browser *rod.Browser, navigationTimeout time.Duration) Browser {
+	return &rodBrowser{browser: browser, navigationTimeout: navigationTimeout}
+}
+
+func (b *rodBrowser) NewPage() (Page, error) {
+	page, err := b.browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return nil, err
+	}
+
+	// event subscriptions of page live as long as its context, it is cancelled on Close
+	ctx, cancel := context.WithCancel(page.GetContext())
+	page = page.Context(ctx)
+
+	p := &rodPage{page: page, cancel: cancel, navigationTimeout: b.navigationTimeout, isHuman: b.stealth != nil}
+	go p.collectConsole()
+
+	if b.stealth != nil {
+		if err = b.stealth.apply(page); err != nil {
+			_ = p.Close()
+			return nil, fmt.Errorf("failed to set up stealth mode: %v", err)
+		}
+	}
+
+	if b.proxyAuth != nil {
+		if err = p.handleProxyAuth(b.proxyAuth); err != nil {
+			_ = p.Close()
+			return nil, fmt.Errorf("failed to set up proxy auth: %v", err)
+		}
+	}
+
+	return p, nil
+}
+
+// NewContext opens incognito context, same as rod.Browser.Incognito but with proxy and stealth options
+func (b *rodBrowser) NewContext(opts ContextOptions) (BrowserContext, error) {
+	req := proto.TargetCreateBrowserContext{}
+	var proxyAuth *url.Userinfo
+	if opts.Proxy != nil {
+		// chrome takes proxy without credentials, they are provided on auth challenge
+		req.ProxyServer = opts.Proxy.Scheme + "://" + opts.Proxy.Host
+		proxyAuth = opts.Proxy.User
+	}
+
+	res, err := req.Call(b.browser)
+	if err != nil {
+		return nil, err
+	}
+
+	incognito := *b.browser
+	incognito.BrowserContextID = res.BrowserContextID
+
+	var stealth *stealthProfile
+	if opts.Stealth {
+		stealth = newStealthProfile()
+	}
+
+	return &rodBrowser{browser: &incognito, navigationTimeout: b.navigationTimeout, proxyAuth: proxyAuth, stealth: stealth}, nil
+}
+
+// Close disposes incognito context, must not be called on browser returned by NewRodBrowser
+// since it would close shared remote browser
+func (b *rodBrowser) Close() error {
+	return b.browser.Close()
+}
+
+type rodPage struct {
+	page *rod.Page
+	// cancel stops goroutines listening to page events
+	cancel            context.CancelFunc
+	navigationTimeout time.Duration
+	// isHuman makes elements of page click and type like a person
+	isHuman bool
+
+	consoleMu sync.Mutex
+	console   []string
+}
+
+// records console messages, exceptions and network errors until page is closed
+func (p *rodPage) collectConsole() {
+	requestUrls := make(map[proto.NetworkRequestID]string)
+
+	p.page.EachEvent(func(e *proto.RuntimeConsoleAPICalled) {
+		args := make([]string, 0, len(e.Args))
+		for _, arg := range e.Args {
+			if arg.Description != "" {
+				args = append(args, arg.Description)
+				continue
+			}
+			args = append(args, arg.Value.String())
+		}
+
+		p.addConsole(fmt.Sprintf("console.%s: %s", e.Type, strings.Join(args, " ")))
+	}, func(e *proto.RuntimeExceptionThrown) {
+		p.addConsole(fmt.Sprintf("exception: %s", e.ExceptionDetails.Text))
+	}, func(e *proto.NetworkRequestWillBeSent) {
+		requestUrls[e.RequestID] = e.Request.URL
+	}, func(e *proto.NetworkResponseReceived) {
+		if e.Response.Status >= 400 {
+			p.addConsole(fmt.Sprintf("network: %d %s", e.Response.Status, e.Response.URL))
+		}
+	}, func(e *proto.NetworkLoadingFailed) {
+		if !e.Canceled {
+			p.addConsole(fmt.Sprintf("network: %s %s", e.ErrorText, requestUrls[e.RequestID]))
+		}
+	})()
+}
+
+// intercepts requests of page to answer proxy auth challenges, every other request is let through as is;
+// listening stops along with the other page subscriptions when page is closed
+func (p *rodPage) handleProxyAuth(auth *url.Userinfo) error {
+	password, _ := auth.Password()
+
+	// subscribed before fetch is enabled, so no paused request is missed
+	wait := p.page.EachEvent(func(e *proto.FetchRequestPaused) {
+		_ = proto.FetchContinueRequest{RequestID: e.RequestID}.Call(p.page)
+	}, func(e *proto.FetchAuthRequired) {
+		response := &proto.FetchAuthChallengeResponse{
+			Response: proto.FetchAuthChallengeResponseResponseDefault,
+		}
+		if e.AuthChallenge.Source == proto.FetchAuthChallengeSourceProxy {
+			response = &proto.FetchAuthChallengeResponse{
+				Response: proto.FetchAuthChallengeResponseResponseProvideCredentials,
+				Username: auth.Username(),
+				Password: password,
+			}
+		}
+
+		_ = proto.FetchContinueWithAuth{RequestID: e.RequestID, AuthChallengeResponse: response}.Call(p.page)
+	})
+	go wait()
+
+	return proto.FetchEnable{HandleAuthRequests: true}.Call(p.page)
+}
+
+func (p *rodPage) addConsole(line string) {
+	p.consoleMu.Lock()
+	defer p.consoleMu.Unlock()
+
+	p.console = append(p.console, line)
+}
+
+func (p *rodPage) Navigate(url string) error {
+	page := p.withTimeout()
+	defer page.CancelTimeout()
+
+	err := page.Navigate(url)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrNavigationTimeout
+	}
+
+	return err
+}
+
+func (p *rodPage) WaitNavigation() func() error {
+	page := p.withTimeout()
+	wait := page.WaitNavigation(proto.PageLifecycleEventNameNetworkIdle)
+
+	return func() error {
+		defer page.CancelTimeout()
+
+		wait()
+		if errors.Is(page.GetContext().Err(), context.DeadlineExceeded) {
+			return ErrNavigationTimeout
+		}
+
+		return nil
+	}
+}
+
+// returns page clone with navigation timeout, CancelTimeout must be called on it when done
+func (p *rodPage) withTimeout() *rod.Page {
+	if p.navigationTimeout <= 0 {
+		return p.page.Context(p.page.GetContext())
+	}
+
+	return p.page.Timeout(p.navigationTimeout)
+}
+
+func (p *rodPage) Element(sel selector.Selector) (Element, error) {
+	el, err := p.page.Sleeper(rod.NotFoundSleeper).Element(sel.String())
+	if err != nil {
+		return nil, ErrElementNotFound
+	}
+
+	return &rodElement{el: el, isHuman: p.isHuman}, nil
+}
+
+func (p *rodPage) Elements(sel selector.Selector) ([]Element, error) {
+	elements, err := p.page.Elements(sel.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapRodElements(elements, p.isHuman), nil
+}
+
+func (p *rodPage) Press(key Key) error {
+	return p.page.KeyActions().Press(rodKeys[key]).Do()
+}
+
+func (p *rodPage) Close() error {
+	defer p.cancel()
+
+	return p.page.Close()
+}
+
+func (p *rodPage) URL() (string, error) {
+	info, err := p.page.Info()
+	if err != nil {
+		return "", err
+	}
+
+	return info.URL, nil
+}
+
+func (p *rodPage) HTML() (string, error) {
+	return p.page.HTML()
+}
+
+func (p *rodPage) Screenshot() ([]byte, error) {
+	return p.page.Screenshot(true, &proto.PageCaptureScreenshot{
+		Format: proto.PageCaptureScreenshotFormatPng,
+	})
+}
+
+func (p *rodPage) Console() []string {
+	p.consoleMu.Lock()
+	defer p.consoleMu.Unlock()
+
+	return append([]string(nil), p.console...)
+}
+
+type rodElement struct {
+	el      *rod.Element
+	isHuman bool
+}
+
+func (e *rodElement) Click() error {
+	if e.isHuman {
+		return humanClick(e.el)
+	}
+
+	return e.el.Click(proto.InputMouseButtonLeft, 1)
+}
+
+func (e *rodElement) Text() (string, error) {
+	return e.el.Text()
+}
+
+func (e *rodElement) Attribute(name string) (*string, error) {
+	return e.el.Attribute(name)
+}
+
+func (e *rodElement) Elements(sel selector.Selector) ([]Element, error) {
+	elements, err := e.el.Elements(sel.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapRodElements(elements, e.isHuman), nil
+}
+
+func (e *rodElement) Input(text string) error {
+	if e.isHuman {
+		return humanInput(e.el, text)
+	}
+
+	if err := e.el.SelectAllText(); err != nil {
+		return err
+	}
+
+	return e.el.Input(text)
+}
+
+func wrapRodElements(elements rod.Elements, isHuman bool) []Element {
+	wrapped := make([]Element, 0, len(elements))
+	for _, el := range elements {
+		wrapped = append(wrapped, &rodElement{el: el, isHuman: isHuman})
+	}
+
+	return wrapped
+}
@@ -0,0 +1,170 @@
+package driver
+
+import (
+	_ "embed"
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"math"
+	"math/rand"
+	"time"
+)
+
+//go:embed stealth.js
+var stealthScript string
+
+const stealthAcceptLanguage = "ru-RU,ru;q=0.9,en-US;q=0.8,en;q=0.7"
+
+// user agents of recent desktop chrome, along with platform navigator reports for them
+var stealthUserAgents = []struct {
+	userAgent string
+	platform  string
+}{
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36", "Win32"},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36", "Win32"},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36", "Win32"},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36", "MacIntel"},
+	{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36", "Linux x86_64"},
+}
+
+// common desktop screen sizes
+var stealthViewports = [][2]int{
+	{1366, 768},
+	{1440, 900},
+	{1536, 864},
+	{1600, 900},
+	{1920, 1080},
+}
+
+// stealthProfile is what pages of stealth browser context look like, picked once per context
+// so every page of it has the same fingerprint
+type stealthProfile struct {
+	userAgent string
+	platform  string
+	width     int
+	height    int
+}
+
+func newStealthProfile() *stealthProfile {
+	ua := stealthUserAgents[rand.Intn(len(stealthUserAgents))]
+	viewport := stealthViewports[rand.Intn(len(stealthViewports))]
+
+	return &stealthProfile{
+		userAgent: ua.userAgent,
+		platform:  ua.platform,
+		width:     viewport[0],
+		// browser toolbars take part of the screen height
+		height: viewport[1] - 70 - rand.Intn(40),
+	}
+}
+
+// applies profile to page before it navigates anywhere
+func (s *stealthProfile) apply(page *rod.Page) error {
+	if _, err := page.EvalOnNewDocument(stealthScript); err != nil {
+		return err
+	}
+
+	err := page.SetUserAgent(&proto.NetworkSetUserAgentOverride{
+		UserAgent:      s.userAgent,
+		AcceptLanguage: stealthAcceptLanguage,
+		Platform:       s.platform,
+	})
+	if err != nil {
+		return err
+	}
+
+	return page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{
+		Width:             s.width,
+		Height:            s.height,
+		DeviceScaleFactor: 1,
+	})
+}
+
+// returns random duration in [from, to)
+func randomDelay(from, to time.Duration) time.Duration {
+	return from + time.Duration(rand.Int63n(int64(to-from)))
+}
+
+// moves mouse to random point of element along curved path with uneven speed,
+// pauses a bit like a person aiming and clicks
+func humanClick(el *rod.Element) error {
+	if err := el.ScrollIntoView(); err != nil {
+		return err
+	}
+
+	if err := el.WaitEnabled(); err != nil {
+		return err
+	}
+
+	shape, err := el.Shape()
+	if err != nil {
+		return err
+	}
+
+	// aim somewhere near the middle, people rarely click the very edge
+	box := shape.Box()
+	target := proto.Point{
+		X: box.X + box.Width*(0.3+0.4*rand.Float64()),
+		Y: box.Y + box.Height*(0.3+0.4*rand.Float64()),
+	}
+
+	mouse := el.Page().Mouse
+	if err = humanMove(mouse, target); err != nil {
+		return err
+	}
+
+	time.Sleep(randomDelay(80*time.Millisecond, 250*time.Millisecond))
+	if err = mouse.Down(proto.InputMouseButtonLeft, 1); err != nil {
+		return err
+	}
+
+	time.Sleep(randomDelay(40*time.Millisecond, 120*time.Millisecond))
+
+	return mouse.Up(proto.InputMouseButtonLeft, 1)
+}
+
+// moves mouse along cubic bezier curve with random control points,
+// steps are denser at both ends, so pointer speeds up and slows down
+func humanMove(mouse *rod.Mouse, to proto.Point) error {
+	from := mouse.Position()
+	distance := math.Hypot(to.X-from.X, to.Y-from.Y)
+
+	// control points are off the straight line by up to a third of the distance
+	spread := distance / 3
+	c1 := proto.Point{X: from.X + (to.X-from.X)/3 + (rand.Float64()-0.5)*spread, Y: from.Y + (to.Y-from.Y)/3 + (rand.Float64()-0.5)*spread}
+	c2 := proto.Point{X: from.X + 2*(to.X-from.X)/3 + (rand.Float64()-0.5)*spread, Y: from.Y + 2*(to.Y-from.Y)/3 + (rand.Float64()-0.5)*spread}
+
+	steps := 10 + int(distance/25) + rand.Intn(10)
+	step := 0
+
+	return mouse.MoveAlong(func() (proto.Point, bool) {
+		step++
+		// ease in and out
+		t := float64(step) / float64(steps)
+		t = t * t * (3 - 2*t)
+
+		time.Sleep(randomDelay(5*time.Millisecond, 20*time.Millisecond))
+
+		u := 1 - t
+		return proto.Point{
+			X: u*u*u*from.X + 3*u*u*t*c1.X + 3*u*t*t*c2.X + t*t*t*to.X,
+			Y: u*u*u*from.Y + 3*u*u*t*c1.Y + 3*u*t*t*c2.Y + t*t*t*to.Y,
+		}, step >= steps
+	})
+}
+
+// types text one character at a time with uneven pauses between keystrokes
+func humanInput(el *rod.Element, text string) error {
+	if err := el.SelectAllText(); err != nil {
+		return err
+	}
+
+	for _, r := range text {
+		if err := el.Input(string(r)); err != nil {
+			return err
+		}
+
+		time.Sleep(randomDelay(60*time.Millisecond, 220*time.Millisecond))
+	}
+
+	return nil
+}
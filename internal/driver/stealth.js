@@ -0,0 +1,155 @@
+// evasions run before any page script, in the spirit of go-rod/stealth:
+// hide traces of automation that headless chrome leaves in navigator and window
+(() => {
+    // overridden functions pretend to be native when stringified
+    const nativeNames = new WeakMap();
+    const nativeFns = {
+        add: (fn, name) => nativeNames.set(fn, name || fn.name),
+    };
+    const nativeToString = Function.prototype.toString;
+    const toString = function toString() {
+        if (nativeNames.has(this)) {
+            return `function ${nativeNames.get(this)}() { [native code] }`;
+        }
+        return nativeToString.call(this);
+    };
+    nativeFns.add(toString);
+    Function.prototype.toString = toString;
+
+    const defineGetter = (target, name, get) => {
+        nativeFns.add(get, `get ${name}`);
+        Object.defineProperty(target, name, {get, configurable: true, enumerable: true});
+    };
+
+    // navigator.webdriver is set when browser is controlled over devtools
+    defineGetter(Navigator.prototype, 'webdriver', function webdriver() {
+        return undefined;
+    });
+
+    defineGetter(Navigator.prototype, 'languages', function languages() {
+        return ['ru-RU', 'ru', 'en-US', 'en'];
+    });
+
+    defineGetter(Navigator.prototype, 'hardwareConcurrency', function hardwareConcurrency() {
+        return 8;
+    });
+
+    // headless chrome has neither chrome.runtime nor chrome.app
+    if (!window.chrome) {
+        Object.defineProperty(window, 'chrome', {value: {}, writable: true, configurable: true});
+    }
+    if (!window.chrome.app) {
+        window.chrome.app = {
+            isInstalled: false,
+            InstallState: {DISABLED: 'disabled', INSTALLED: 'installed', NOT_INSTALLED: 'not_installed'},
+            RunningState: {CANNOT_RUN: 'cannot_run', READY_TO_RUN: 'ready_to_run', RUNNING: 'running'},
+        };
+    }
+    if (!window.chrome.runtime) {
+        window.chrome.runtime = {
+            OnInstalledReason: {CHROME_UPDATE: 'chrome_update', INSTALL: 'install', SHARED_MODULE_UPDATE: 'shared_module_update', UPDATE: 'update'},
+            PlatformOs: {ANDROID: 'android', CROS: 'cros', LINUX: 'linux', MAC: 'mac', OPENBSD: 'openbsd', WIN: 'win'},
+        };
+    }
+    if (!window.chrome.csi) {
+        window.chrome.csi = function csi() {
+            return {onloadT: Date.now(), startE: Date.now(), pageT: performance.now(), tran: 15};
+        };
+        nativeFns.add(window.chrome.csi);
+    }
+    if (!window.chrome.loadTimes) {
+        window.chrome.loadTimes = function loadTimes() {
+            const now = Date.now() / 1000;
+            return {
+                requestTime: now, startLoadTime: now, commitLoadTime: now, finishDocumentLoadTime: now,
+                finishLoadTime: now, firstPaintTime: now, firstPaintAfterLoadTime: 0, navigationType: 'Other',
+                wasFetchedViaSpdy: true, wasNpnNegotiated: true, npnNegotiatedProtocol: 'h2',
+                wasAlternateProtocolAvailable: false, connectionInfo: 'h2',
+            };
+        };
+        nativeFns.add(window.chrome.loadTimes);
+    }
+
+    // headless chrome reports no plugins, regular one has built-in pdf viewer
+    if (navigator.plugins.length === 0) {
+        const mimeType = {type: 'application/pdf', suffixes: 'pdf', description: 'Portable Document Format'};
+        const names = ['PDF Viewer', 'Chrome PDF Viewer', 'Chromium PDF Viewer', 'Microsoft Edge PDF Viewer', 'WebKit built-in PDF'];
+        const plugins = names.map((name) => {
+            const plugin = {name, filename: 'internal-pdf-viewer', description: 'Portable Document Format', length: 1, 0: mimeType};
+            Object.setPrototypeOf(plugin, Plugin.prototype);
+            return plugin;
+        });
+        plugins.item = function item(i) {
+            return plugins[i] || null;
+        };
+        plugins.namedItem = function namedItem(name) {
+            return plugins.find((p) => p.name === name) || null;
+        };
+        plugins.refresh = function refresh() {
+        };
+        [plugins.item, plugins.namedItem, plugins.refresh].forEach((fn) => nativeFns.add(fn));
+        Object.setPrototypeOf(plugins, PluginArray.prototype);
+
+        const mimeTypes = [mimeType];
+        mimeTypes.item = function item(i) {
+            return mimeTypes[i] || null;
+        };
+        mimeTypes.namedItem = function namedItem(type) {
+            return mimeTypes.find((m) => m.type === type) || null;
+        };
+        [mimeTypes.item, mimeTypes.namedItem].forEach((fn) => nativeFns.add(fn));
+        Object.setPrototypeOf(mimeTypes, MimeTypeArray.prototype);
+
+        defineGetter(Navigator.prototype, 'plugins', function getPlugins() {
+            return plugins;
+        });
+        defineGetter(Navigator.prototype, 'mimeTypes', function getMimeTypes() {
+            return mimeTypes;
+        });
+    }
+
+    // headless chrome denies notifications permission while reporting it as default
+    if (navigator.permissions && navigator.permissions.query) {
+        const query = navigator.permissions.query.bind(navigator.permissions);
+        const patchedQuery = function query_(parameters) {
+            if (parameters && parameters.name === 'notifications') {
+                return Promise.resolve({state: Notification.permission, onchange: null});
+            }
+            return query(parameters);
+        };
+        nativeFns.add(patchedQuery, 'query');
+        Permissions.prototype.query = patchedQuery;
+    }
+
+    // software renderer of headless chrome gives it away through webgl
+    const patchWebGl = (proto) => {
+        if (!proto) {
+            return;
+        }
+        const getParameter = proto.getParameter;
+        const patchedGetParameter = function getParameter_(parameter) {
+            // UNMASKED_VENDOR_WEBGL and UNMASKED_RENDERER_WEBGL
+            if (parameter === 37445) {
+                return 'Intel Inc.';
+            }
+            if (parameter === 37446) {
+                return 'Intel Iris OpenGL Engine';
+            }
+            return getParameter.call(this, parameter);
+        };
+        nativeFns.add(patchedGetParameter, 'getParameter');
+        proto.getParameter = patchedGetParameter;
+    };
+    patchWebGl(window.WebGLRenderingContext && WebGLRenderingContext.prototype);
+    patchWebGl(window.WebGL2RenderingContext && WebGL2RenderingContext.prototype);
+
+    // headless window has no browser frame around it
+    if (window.outerWidth === 0 && window.outerHeight === 0) {
+        defineGetter(window, 'outerWidth', function outerWidth() {
+            return window.innerWidth;
+        });
+        defineGetter(window, 'outerHeight', function outerHeight() {
+            return window.innerHeight + 85;
+        });
+    }
+})();
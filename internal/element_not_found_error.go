@@ -23,3 +23,11 @@ func (e ElementNotFoundError) Is(target error) bool {
 	ok := errors.As(target, &t)
 	return ok
 }
+
+func (e ElementNotFoundError) Kind() ErrorKind {
+	return ErrorKindElementNotFound
+}
+
+func (e ElementNotFoundError) SelectorString() string {
+	return e.Selector
+}
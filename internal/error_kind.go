@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrorKind classifies task errors, stored with failed task attempts
+type ErrorKind string
+
+const (
+	ErrorKindUnknown           ErrorKind = "unknown"
+	ErrorKindElementNotFound   ErrorKind = "element_not_found"
+	ErrorKindLocationNotFound  ErrorKind = "location_not_found"
+	ErrorKindTitleMismatch     ErrorKind = "title_mismatch"
+	ErrorKindUnknownPage       ErrorKind = "unknown_page"
+	ErrorKindCountParse        ErrorKind = "count_parse"
+	ErrorKindNavigationTimeout ErrorKind = "navigation_timeout"
+	ErrorKindBlocked           ErrorKind = "blocked"
+	ErrorKindLocationMismatch  ErrorKind = "location_mismatch"
+	ErrorKindInvalidResult     ErrorKind = "invalid_result"
+	ErrorKindConfig            ErrorKind = "config"
+	// ErrorKindTargetFilterNotFound is not a plain element_not_found,
+	// widget is there but does not offer estate type of the target
+	ErrorKindTargetFilterNotFound ErrorKind = "target_filter_not_found"
+)
+
+var errorKinds = []ErrorKind{
+	ErrorKindUnknown,
+	ErrorKindElementNotFound,
+	ErrorKindLocationNotFound,
+	ErrorKindTitleMismatch,
+	ErrorKindUnknownPage,
+	ErrorKindCountParse,
+	ErrorKindNavigationTimeout,
+	ErrorKindBlocked,
+	ErrorKindLocationMismatch,
+	ErrorKindInvalidResult,
+	ErrorKindConfig,
+	ErrorKindTargetFilterNotFound,
+}
+
+// ParseErrorKind returns error kind with given name
+func ParseErrorKind(s string) (ErrorKind, error) {
+	if kind := ErrorKind(s); slices.Contains(errorKinds, kind) {
+		return kind, nil
+	}
+
+	return "", fmt.Errorf("unknown error kind %q", s)
+}
+
+type kindError interface {
+	Kind() ErrorKind
+}
+
+type selectorError interface {
+	SelectorString() string
+}
+
+// ErrorKindOf returns kind of the first typed error in err chain
+func ErrorKindOf(err error) ErrorKind {
+	var e kindError
+	if errors.As(err, &e) {
+		return e.Kind()
+	}
+
+	return ErrorKindUnknown
+}
+
+// ErrorSelector returns selector of the first error in err chain that has one
+func ErrorSelector(err error) string {
+	var e selectorError
+	if errors.As(err, &e) {
+		return e.SelectorString()
+	}
+
+	return ""
+}
@@ -0,0 +1,203 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatCsv   Format = "csv"
+	FormatXlsx  Format = "xlsx"
+	FormatJsonl Format = "jsonl"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatCsv, FormatXlsx, FormatJsonl:
+		return f, nil
+	}
+
+	return "", fmt.Errorf("unknown export format %q, expected one of csv, xlsx, jsonl", s)
+}
+
+var header = []string{
+	"task_id", "location_id", "location_name", "target_id", "target_name",
+	"date_start", "date_end", "observed_at", "total_count", "free_count", "occupancy_rate", "suspect_reason",
+}
+
+// Write writes values in given format, xlsx gets one sheet per location
+func Write(w io.Writer, format Format, values []*db.ValueSeriesModel) error {
+	switch format {
+	case FormatCsv:
+		return writeCsv(w, values)
+	case FormatXlsx:
+		return writeXlsx(w, values)
+	case FormatJsonl:
+		return writeJsonl(w, values)
+	}
+
+	return fmt.Errorf("unknown export format %q", format)
+}
+
+func writeCsv(w io.Writer, values []*db.ValueSeriesModel) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		occupancyRate := ""
+		if v.OccupancyRate != nil {
+			occupancyRate = strconv.FormatFloat(*v.OccupancyRate, 'f', 4, 64)
+		}
+
+		observedAt := ""
+		if v.ObservedAt != nil {
+			observedAt = v.ObservedAt.Format(time.RFC3339)
+		}
+
+		err := cw.Write([]string{
+			strconv.Itoa(v.TaskId),
+			strconv.Itoa(v.LocationId),
+			v.LocationName,
+			strconv.Itoa(v.TargetId),
+			v.TargetName,
+			v.DateStart.Format(time.DateOnly),
+			v.DateEnd.Format(time.DateOnly),
+			observedAt,
+			strconv.Itoa(v.EstateTotalCount),
+			strconv.Itoa(v.EstateFreeCount),
+			occupancyRate,
+			v.SuspectReason,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func writeJsonl(w io.Writer, values []*db.ValueSeriesModel) error {
+	// encoder terminates every value with newline, which is all json lines needs
+	enc := json.NewEncoder(w)
+	for _, v := range values {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeXlsx(w io.Writer, values []*db.ValueSeriesModel) error {
+	file := excelize.NewFile()
+	defer func() {
+		_ = file.Close()
+	}()
+
+	// sheets are created in order locations first appear in values
+	sheets := make(map[int]string)
+	rows := make(map[string]int)
+	usedNames := make(map[string]bool)
+	for _, v := range values {
+		sheet, ok := sheets[v.LocationId]
+		if !ok {
+			sheet = sheetName(v.LocationName, v.LocationId, usedNames)
+			sheets[v.LocationId] = sheet
+			usedNames[sheet] = true
+
+			if _, err := file.NewSheet(sheet); err != nil {
+				return err
+			}
+			if err := file.SetSheetRow(sheet, "A1", &header); err != nil {
+				return err
+			}
+			rows[sheet] = 1
+		}
+
+		rows[sheet]++
+		cell, err := excelize.CoordinatesToCellName(1, rows[sheet])
+		if err != nil {
+			return err
+		}
+
+		var occupancyRate any
+		if v.OccupancyRate != nil {
+			occupancyRate = *v.OccupancyRate
+		}
+
+		var observedAt any
+		if v.ObservedAt != nil {
+			observedAt = v.ObservedAt.Format(time.DateTime)
+		}
+
+		err = file.SetSheetRow(sheet, cell, &[]any{
+			v.TaskId,
+			v.LocationId,
+			v.LocationName,
+			v.TargetId,
+			v.TargetName,
+			v.DateStart.Format(time.DateOnly),
+			v.DateEnd.Format(time.DateOnly),
+			observedAt,
+			v.EstateTotalCount,
+			v.EstateFreeCount,
+			occupancyRate,
+			v.SuspectReason,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// new file comes with default sheet, it is only kept when there is nothing to export
+	if len(sheets) > 0 {
+		if err := file.DeleteSheet("Sheet1"); err != nil {
+			return err
+		}
+	}
+
+	return file.Write(w)
+}
+
+// returns valid unique sheet name: at most 31 chars without []:*?/\
+func sheetName(locationName string, locationId int, usedNames map[string]bool) string {
+	const maxLength = 31
+
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, locationName)
+
+	if name == "" {
+		name = "location"
+	}
+	if runes := []rune(name); len(runes) > maxLength {
+		name = string(runes[:maxLength])
+	}
+
+	if !usedNames[name] && name != "Sheet1" {
+		return name
+	}
+
+	suffix := fmt.Sprintf(" (%d)", locationId)
+	if runes := []rune(name); len(runes)+len(suffix) > maxLength {
+		name = string(runes[:maxLength-len(suffix)])
+	}
+
+	return name + suffix
+}
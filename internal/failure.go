@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
+	"github.com/uptrace/bun"
+)
+
+// ParsingTaskFailure is a single failed attempt of parsing task
+type ParsingTaskFailure struct {
+	Task    *ParsingTask
+	Attempt int
+	Err     error
+	Kind    ErrorKind
+	// Url is the page task was on when it failed
+	Url string
+	// SnapshotPath is empty when snapshots are disabled or failed to save
+	SnapshotPath string
+	// Proxy attempt went through, empty when no proxy is configured
+	Proxy string
+}
+
+func NewParsingTaskFailure(task *ParsingTask, attempt int, err error, url string, snapshotPath string) *ParsingTaskFailure {
+	return &ParsingTaskFailure{
+		Task:         task,
+		Attempt:      attempt,
+		Err:          err,
+		Kind:         ErrorKindOf(err),
+		Url:          url,
+		SnapshotPath: snapshotPath,
+	}
+}
+
+func SaveTaskFailures(ctx context.Context, connection bun.IDB, runId string, failures []*ParsingTaskFailure) (int, error) {
+	models := make([]*db.EstateParsingFailureModel, 0, len(failures))
+	for _, failure := range failures {
+		models = append(models, &db.EstateParsingFailureModel{
+			RunId:        runId,
+			TaskId:       failure.Task.Id,
+			DateStart:    failure.Task.DateStart,
+			DateEnd:      failure.Task.DateEnd,
+			Attempt:      failure.Attempt,
+			ErrorKind:    string(failure.Kind),
+			Selector:     ErrorSelector(failure.Err),
+			Url:          failure.Url,
+			Message:      failure.Err.Error(),
+			SnapshotPath: failure.SnapshotPath,
+			Proxy:        failure.Proxy,
+		})
+	}
+
+	insertedCount, err := db.SaveFailures(ctx, connection, models)
+	if err != nil {
+		return 0, fmt.Errorf("error savings task failures: %v", err)
+	}
+
+	return insertedCount, nil
+}
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+// InvalidResultError describes implausible parsing result, suspect result may be stored
+// with the reason when configured so, the rest are never stored
+type InvalidResultError struct {
+	Reason  string
+	Suspect bool
+}
+
+func NewInvalidResultError(reason string, suspect bool) *InvalidResultError {
+	return &InvalidResultError{Reason: reason, Suspect: suspect}
+}
+
+func (e InvalidResultError) Error() string {
+	if e.Suspect {
+		return fmt.Sprintf("suspect result: %s", e.Reason)
+	}
+
+	return fmt.Sprintf("invalid result: %s", e.Reason)
+}
+
+func (e InvalidResultError) Is(target error) bool {
+	var t *InvalidResultError
+	ok := errors.As(target, &t)
+	return ok
+}
+
+func (e InvalidResultError) Kind() ErrorKind {
+	return ErrorKindInvalidResult
+}
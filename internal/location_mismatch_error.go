@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+type LocationMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func NewLocationMismatchError(expected string, actual string) *LocationMismatchError {
+	return &LocationMismatchError{Expected: expected, Actual: actual}
+}
+
+func (e LocationMismatchError) Error() string {
+	return fmt.Sprintf("location %q does not match expected %q", e.Actual, e.Expected)
+}
+
+func (e LocationMismatchError) Is(target error) bool {
+	var t *LocationMismatchError
+	ok := errors.As(target, &t)
+	return ok
+}
+
+func (e LocationMismatchError) Kind() ErrorKind {
+	return ErrorKindLocationMismatch
+}
@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type LocationNotFoundError struct {
+	Location    string
+	Suggestions []string
+}
+
+func NewLocationNotFoundError(location string, suggestions []string) *LocationNotFoundError {
+	return &LocationNotFoundError{Location: location, Suggestions: suggestions}
+}
+
+func (e LocationNotFoundError) Error() string {
+	return fmt.Sprintf("location %q not found among suggestions [%s]", e.Location, strings.Join(e.Suggestions, ", "))
+}
+
+func (e LocationNotFoundError) Is(target error) bool {
+	var t *LocationNotFoundError
+	ok := errors.As(target, &t)
+	return ok
+}
+
+func (e LocationNotFoundError) Kind() ErrorKind {
+	return ErrorKindLocationNotFound
+}
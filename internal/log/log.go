@@ -6,16 +6,21 @@ import (
 	"github.com/nullseed/logruseq"
 	"github.com/sirupsen/logrus"
 	"os"
+	"sync"
 )
 
+// globals are replaced by StartTrace while api handlers may be logging with them
+var mu sync.RWMutex
 var entry *logrus.Entry
+var traceId string
 
 type Logger = *logrus.Entry
 
 func InitLogger(config *util.Config) {
 
+	// stdout is left to command output, eg. export and config print
 	logger := logrus.Logger{
-		Out:   os.Stdout,
+		Out:   os.Stderr,
 		Hooks: make(logrus.LevelHooks),
 		Level: logrus.DebugLevel,
 	}
@@ -37,15 +42,44 @@ func InitLogger(config *util.Config) {
 		logger.Warn("logger running without seq hook")
 	}
 
-	u := uuid.New().String()
-	entry = logger.WithField("TraceId", u)
+	mu.Lock()
+	defer mu.Unlock()
+
+	traceId = uuid.New().String()
+	entry = logger.WithField("TraceId", traceId)
 }
 
 func AddGlobalField(name string, value interface{}) Logger {
+	mu.Lock()
+	defer mu.Unlock()
+
 	entry = entry.WithField(name, value)
 	return entry
 }
 
 func GetLogger() Logger {
+	mu.RLock()
+	defer mu.RUnlock()
+
 	return entry
 }
+
+// GetTraceId returns id of current run attached to every log entry
+func GetTraceId() string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return traceId
+}
+
+// StartTrace replaces TraceId of global logger with a new one,
+// used by long-running modes to tell apart runs within one process
+func StartTrace() string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	traceId = uuid.New().String()
+	entry = entry.WithField("TraceId", traceId)
+
+	return traceId
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// registry holds parser metrics only, so textfile does not clash
+// with go runtime metrics node_exporter exposes itself
+var registry = prometheus.NewRegistry()
+
+var (
+	tasksSucceeded = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "avito_parser_tasks_succeeded_total",
+		Help: "Number of tasks parsed successfully.",
+	})
+	tasksFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "avito_parser_tasks_failed_total",
+		Help: "Number of tasks failed on every attempt, by error kind of the last attempt.",
+	}, []string{"error_kind"})
+	attempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "avito_parser_task_attempts_total",
+		Help: "Number of task attempts, by outcome and error kind, which is none for succeeded ones.",
+	}, []string{"result", "error_kind"})
+	taskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "avito_parser_task_duration_seconds",
+		Help:    "Time spent on task including retries.",
+		Buckets: []float64{5, 10, 20, 30, 60, 120, 300, 600},
+	}, []string{"task_id"})
+	// counts are labeled by date window, since one run parses several windows of task in sweep mode
+	freeCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "avito_parser_estate_free_count",
+		Help: "Free estate objects count of task date window parsed by the last run.",
+	}, []string{"task_id", "location", "target", "date_start", "date_end"})
+	totalCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "avito_parser_estate_total_count",
+		Help: "Total estate objects count of task date window parsed by the last run.",
+	}, []string{"task_id", "location", "target", "date_start", "date_end"})
+	lastSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "avito_parser_last_success_timestamp_seconds",
+		Help: "Unix time of the last run completed without error.",
+	})
+)
+
+func init() {
+	registry.MustRegister(tasksSucceeded, tasksFailed, attempts, taskDuration, freeCount, totalCount, lastSuccess)
+}
+
+// errorKindNone labels succeeded attempts, so every series has explicit error kind
+const errorKindNone = "none"
+
+// ObserveAttempt counts single task attempt, errorKind is empty for succeeded one
+func ObserveAttempt(errorKind string) {
+	if errorKind == "" {
+		attempts.WithLabelValues("success", errorKindNone).Inc()
+		return
+	}
+
+	attempts.WithLabelValues("failure", errorKind).Inc()
+}
+
+// ObserveTaskSuccess records task date window completed with given counts
+func ObserveTaskSuccess(taskId int, location string, target string, dateStart time.Time, dateEnd time.Time, duration time.Duration, free int, total int) {
+	id := strconv.Itoa(taskId)
+	start, end := dateStart.Format(time.DateOnly), dateEnd.Format(time.DateOnly)
+
+	tasksSucceeded.Inc()
+	taskDuration.WithLabelValues(id).Observe(duration.Seconds())
+	freeCount.WithLabelValues(id, location, target, start, end).Set(float64(free))
+	totalCount.WithLabelValues(id, location, target, start, end).Set(float64(total))
+}
+
+// ResetEstateCounts drops counts of given tasks parsed by previous runs, so windows that already passed
+// are not exposed forever by long-running serve mode; counts of other tasks are kept
+func ResetEstateCounts(taskIds []int) {
+	for _, taskId := range taskIds {
+		labels := prometheus.Labels{"task_id": strconv.Itoa(taskId)}
+		freeCount.DeletePartialMatch(labels)
+		totalCount.DeletePartialMatch(labels)
+	}
+}
+
+// ObserveTaskFailure records task that failed every attempt
+func ObserveTaskFailure(taskId int, duration time.Duration, errorKind string) {
+	tasksFailed.WithLabelValues(errorKind).Inc()
+	taskDuration.WithLabelValues(strconv.Itoa(taskId)).Observe(duration.Seconds())
+}
+
+// SetLastSuccess records time of the last run completed without error
+func SetLastSuccess(t time.Time) {
+	lastSuccess.Set(float64(t.Unix()))
+}
+
+// Handler serves parser metrics along with go runtime and process ones
+func Handler() http.Handler {
+	gatherers := prometheus.Gatherers{registry, prometheus.DefaultGatherer}
+
+	return promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{})
+}
+
+// WriteTextfile writes parser metrics for node_exporter textfile collector,
+// file is replaced atomically so collector never reads it half written
+func WriteTextfile(path string) error {
+	return prometheus.WriteToTextfile(path, registry)
+}
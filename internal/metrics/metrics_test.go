@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"testing"
+)
+
+func TestObserveAttempt(t *testing.T) {
+	tests := []struct {
+		name       string
+		errorKind  string
+		wantResult string
+		wantKind   string
+	}{
+		{name: "success", errorKind: "", wantResult: "success", wantKind: "none"},
+		{name: "failure", errorKind: "blocked", wantResult: "failure", wantKind: "blocked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := attempts.WithLabelValues(tt.wantResult, tt.wantKind)
+			before := testutil.ToFloat64(counter)
+
+			ObserveAttempt(tt.errorKind)
+
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("expected attempt to be counted once under %s/%s, got %v", tt.wantResult, tt.wantKind, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+type NavigationTimeoutError struct {
+	Url string
+}
+
+func NewNavigationTimeoutError(url string) *NavigationTimeoutError {
+	return &NavigationTimeoutError{Url: url}
+}
+
+func (e NavigationTimeoutError) Error() string {
+	return fmt.Sprintf("navigation to %s timed out", e.Url)
+}
+
+func (e NavigationTimeoutError) Is(target error) bool {
+	var t *NavigationTimeoutError
+	ok := errors.As(target, &t)
+	return ok
+}
+
+func (e NavigationTimeoutError) Kind() ErrorKind {
+	return ErrorKindNavigationTimeout
+}
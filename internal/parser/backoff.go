@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrTooManyBlocks is returned by Start when run was aborted after too many blocked task attempts,
+// results and failures collected before that are returned along with it
+var ErrTooManyBlocks = errors.New("too many blocked task attempts, run aborted")
+
+// blockBackoff pauses every worker of the run once task attempt is blocked,
+// pause doubles with every block of the run, and after too many of them run is aborted
+type blockBackoff struct {
+	base     time.Duration
+	max      time.Duration
+	maxCount int
+
+	mu          sync.Mutex
+	count       int
+	pausedUntil time.Time
+	// aborted is closed when run is aborted
+	aborted chan struct{}
+}
+
+func newBlockBackoff(opts Options) *blockBackoff {
+	return &blockBackoff{
+		base:     opts.BlockBackoff,
+		max:      opts.BlockBackoffMax,
+		maxCount: opts.MaxBlockCount,
+		aborted:  make(chan struct{}),
+	}
+}
+
+// blocked records blocked attempt, returns pause it caused or true when run is aborted
+func (b *blockBackoff) blocked() (pause time.Duration, isAborted bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.count++
+	if b.maxCount > 0 && b.count >= b.maxCount {
+		if !b.isAborted() {
+			close(b.aborted)
+		}
+
+		return 0, true
+	}
+
+	// without cap doubling still stops short of overflow
+	pause = b.base
+	for i := 1; i < b.count && (b.max <= 0 || pause < b.max) && pause < math.MaxInt64/2; i++ {
+		pause *= 2
+	}
+	if b.max > 0 {
+		pause = min(pause, b.max)
+	}
+
+	if until := time.Now().Add(pause); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+
+	return pause, false
+}
+
+// wait blocks until pause is over, returns false when run is aborted or cancelled
+func (b *blockBackoff) wait(ctx context.Context) bool {
+	b.mu.Lock()
+	pause := time.Until(b.pausedUntil)
+	b.mu.Unlock()
+
+	if pause > 0 {
+		timer := time.NewTimer(pause)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		case <-b.aborted:
+		}
+	}
+
+	return ctx.Err() == nil && !b.isAborted()
+}
+
+func (b *blockBackoff) isAborted() bool {
+	select {
+	case <-b.aborted:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,136 @@
+package parser
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBlockBackoffBlocked(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		// pause of every blocked attempt, zero stands for aborted run
+		want []time.Duration
+	}{
+		{
+			name: "doubles up to max",
+			opts: Options{BlockBackoff: time.Minute, BlockBackoffMax: 5 * time.Minute},
+			want: []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute},
+		},
+		{
+			name: "no cap when max is zero",
+			opts: Options{BlockBackoff: time.Minute},
+			want: []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute},
+		},
+		{
+			name: "max below base",
+			opts: Options{BlockBackoff: time.Minute, BlockBackoffMax: 30 * time.Second},
+			want: []time.Duration{30 * time.Second, 30 * time.Second},
+		},
+		{
+			name: "aborted after too many blocks",
+			opts: Options{BlockBackoff: time.Minute, BlockBackoffMax: 15 * time.Minute, MaxBlockCount: 3},
+			want: []time.Duration{time.Minute, 2 * time.Minute, 0, 0},
+		},
+		{
+			name: "aborted on the first block",
+			opts: Options{BlockBackoff: time.Minute, MaxBlockCount: 1},
+			want: []time.Duration{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBlockBackoff(tt.opts)
+
+			for i, want := range tt.want {
+				pause, isAborted := b.blocked()
+				if isAborted != (want == 0) {
+					t.Fatalf("block %d: expected aborted %v, got %v", i+1, want == 0, isAborted)
+				}
+				if pause != want {
+					t.Errorf("block %d: expected pause %s, got %s", i+1, want, pause)
+				}
+			}
+
+			if b.isAborted() != (tt.want[len(tt.want)-1] == 0) {
+				t.Errorf("expected aborted %v after every block, got %v", tt.want[len(tt.want)-1] == 0, b.isAborted())
+			}
+		})
+	}
+}
+
+func TestBlockBackoffBlockedDoesNotOverflow(t *testing.T) {
+	b := newBlockBackoff(Options{BlockBackoff: time.Hour})
+
+	for i := 0; i < 100; i++ {
+		pause, _ := b.blocked()
+		if pause <= 0 {
+			t.Fatalf("block %d: expected positive pause, got %s", i+1, pause)
+		}
+	}
+}
+
+func TestBlockBackoffWait(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		opts  Options
+		block int
+		want  bool
+	}{
+		{
+			name: "not blocked",
+			ctx:  context.Background(),
+			want: true,
+		},
+		{
+			name:  "paused run resumes",
+			ctx:   context.Background(),
+			opts:  Options{BlockBackoff: 10 * time.Millisecond},
+			block: 1,
+			want:  true,
+		},
+		{
+			name:  "aborted run",
+			ctx:   context.Background(),
+			opts:  Options{BlockBackoff: time.Hour, MaxBlockCount: 1},
+			block: 1,
+			want:  false,
+		},
+		{
+			name:  "cancelled run",
+			ctx:   cancelled,
+			opts:  Options{BlockBackoff: time.Hour},
+			block: 1,
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBlockBackoff(tt.opts)
+			for i := 0; i < tt.block; i++ {
+				b.blocked()
+			}
+
+			done := make(chan bool)
+			go func() {
+				done <- b.wait(tt.ctx)
+			}()
+
+			select {
+			case got := <-done:
+				if got != tt.want {
+					t.Errorf("expected %v, got %v", tt.want, got)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("wait did not return")
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/driver"
+	"github.com/csr-ugra/avito-estate-parser/internal/log"
+	"github.com/csr-ugra/avito-estate-parser/internal/selector"
+	"github.com/csr-ugra/avito-estate-parser/internal/util"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// PageType is kind of avito page parser knows how to handle
+type PageType string
+
+const (
+	PageEstateList       PageType = "estate_list"
+	PageBaseEstateWidget PageType = "base_estate_widget"
+	PageDailyRentWidget  PageType = "daily_rent_widget"
+)
+
+// PageTypes are listed in order pages are checked
+var PageTypes = []PageType{PageEstateList, PageBaseEstateWidget, PageDailyRentWidget}
+
+// SelectorCheck is outcome of looking up single selector on sample page
+type SelectorCheck struct {
+	Page     PageType
+	Name     string
+	Selector selector.Selector
+	// Required selectors must be present on page as soon as it loads,
+	// the rest are only shown after interaction or not on every page
+	Required   bool
+	MatchCount int
+}
+
+func (c *SelectorCheck) Passed() bool {
+	return c.MatchCount > 0
+}
+
+type pageSelector struct {
+	name     string
+	sel      selector.Selector
+	required bool
+}
+
+// returns selectors parser looks up on page of given type, date fills calendar day templates
+func pageSelectors(pageType PageType, date *time.Time) []pageSelector {
+	switch pageType {
+	case PageEstateList:
+		return []pageSelector{
+			{"PageTitleText", selector.PageTitleText, true},
+			{"PageTitleCount", selector.PageTitleCount, true},
+			{"SubmitFiltersBtn", selector.SubmitFiltersBtn, true},
+			{"LocationChangeButton", selector.LocationChangeButton, true},
+			{"ListingCard", selector.ListingCard, true},
+			{"ListingCardTitle", selector.ListingCardTitle, true},
+			{"ListingCardPrice", selector.ListingCardPrice, false},
+			{"ListingCardPriceText", selector.ListingCardPriceText, false},
+			{"ListingCardAddress", selector.ListingCardAddress, false},
+			{"ListingCardSellerType", selector.ListingCardSellerType, false},
+			{"PaginationNextPageButton", selector.PaginationNextPageButton, false},
+			{"CalendarBtn", selector.CalendarBtn(date), false},
+			{"FilterCalendarResetButton", selector.FilterCalendarResetButton, false},
+			{"EmptyResults", selector.EmptyResults, false},
+		}
+	case PageBaseEstateWidget:
+		return []pageSelector{
+			{"PageTitleText", selector.PageTitleText, true},
+			{"LocationChangeButton", selector.LocationChangeButton, true},
+			{"BaseEstateWidgetTypeFilterButton", selector.BaseEstateWidgetTypeFilterButton, true},
+			{"BaseEstateWidgetActionFilterButton", selector.BaseEstateWidgetActionFilterButton, true},
+			{"WidgetSubmitButton", selector.WidgetSubmitButton, true},
+			{"BaseEstateWidgetTypeFilterDropdown", selector.BaseEstateWidgetTypeFilterDropdown, false},
+			{"BaseEstateWidgetDurationDailyRentButton", selector.BaseEstateWidgetDurationDailyRentButton, false},
+		}
+	case PageDailyRentWidget:
+		return []pageSelector{
+			{"PageTitleText", selector.PageTitleText, true},
+			{"LocationChangeButton", selector.LocationChangeButton, true},
+			{"DailyRentWidgetPageCalendarButton", selector.DailyRentWidgetPageCalendarButton, true},
+			{"WidgetSubmitButton", selector.WidgetSubmitButton, true},
+			{"DailyRentWidgetPageCalendarTitle", selector.DailyRentWidgetPageCalendarTitle, false},
+			{"DailyRentWidgetPageCalendarNextMonthButton", selector.DailyRentWidgetPageCalendarNextMonthButton, false},
+			{"DailyRentWidgetPageCalendarDayButton", selector.DailyRentWidgetPageCalendarDayButton(date), false},
+		}
+	}
+
+	return nil
+}
+
+// CheckSelectors opens sample page of every given type and counts matches of selectors
+// parser relies on there; page that failed to open gets zero matches for all its selectors
+func CheckSelectors(cfg *util.Config, opts Options, urls map[PageType]string, date time.Time) ([]*SelectorCheck, error) {
+	logger := log.GetLogger()
+
+	browser, err := getBrowser(cfg.DevtoolsWebsocketUrl.Value, opts.FixtureDir, opts.NavigationTimeout, cfg.IsStealth())
+	if err != nil {
+		return nil, err
+	}
+
+	browserContext, err := browser.NewContext(driver.ContextOptions{Stealth: cfg.IsStealth()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open browser context: %v", err)
+	}
+	defer func() {
+		_ = browserContext.Close()
+	}()
+
+	checks := make([]*SelectorCheck, 0)
+	for _, pageType := range PageTypes {
+		url, ok := urls[pageType]
+		if !ok {
+			continue
+		}
+
+		pageLogger := logger.WithFields(logrus.Fields{
+			"PageType": pageType,
+			"Url":      url,
+		})
+		pageLogger.Info("checking selectors on {PageType} page")
+
+		selectors := pageSelectors(pageType, &date)
+		counts, err := countPageSelectors(browserContext, url, selectors, pageLogger)
+		if err != nil {
+			pageLogger.WithError(err).Error("failed to open sample page")
+		}
+
+		for i, ps := range selectors {
+			check := &SelectorCheck{
+				Page:     pageType,
+				Name:     ps.name,
+				Selector: ps.sel,
+				Required: ps.required,
+			}
+			if counts != nil {
+				check.MatchCount = counts[i]
+			}
+
+			checks = append(checks, check)
+		}
+	}
+
+	return checks, nil
+}
+
+// returns match count of every selector in the same order
+func countPageSelectors(browser driver.Browser, url string, selectors []pageSelector, log log.Logger) ([]int, error) {
+	page, err := browser.NewPage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open page: %v", err)
+	}
+	defer func(page driver.Page) {
+		_ = page.Close()
+	}(page)
+
+	if err = openUrl(page, url, log); err != nil {
+		return nil, err
+	}
+
+	counts := make([]int, 0, len(selectors))
+	for _, ps := range selectors {
+		counts = append(counts, countElements(page, ps.sel))
+	}
+
+	return counts, nil
+}
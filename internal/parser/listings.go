@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal"
+	"github.com/csr-ugra/avito-estate-parser/internal/driver"
+	"github.com/csr-ugra/avito-estate-parser/internal/log"
+	"github.com/csr-ugra/avito-estate-parser/internal/selector"
+	"github.com/csr-ugra/avito-estate-parser/internal/util"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+)
+
+const avitoBaseUrl = "https://www.avito.ru"
+
+// collects every listing card rendered on the current estate list page,
+// cards that can't be parsed are skipped since the counts are what matters most
+func parseListings(page driver.Page, log log.Logger) (listings []*internal.Listing, err error) {
+	cards, err := page.Elements(selector.ListingCard)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing cards: %v", err)
+	}
+
+	listings = make([]*internal.Listing, 0, len(cards))
+	for _, card := range cards {
+		listing, err := parseListingCard(card)
+		if err != nil {
+			log.WithError(err).Warn("failed to parse listing card, skipping")
+			continue
+		}
+
+		listings = append(listings, listing)
+	}
+
+	return listings, nil
+}
+
+func parseListingCard(card driver.Element) (listing *internal.Listing, err error) {
+	itemId, err := getAttribute(card, "data-item-id")
+	if err != nil {
+		return nil, err
+	}
+
+	avitoId, err := strconv.ParseInt(itemId, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid item id %q: %v", itemId, err)
+	}
+
+	listing = &internal.Listing{AvitoId: avitoId}
+
+	if title := findChild(card, selector.ListingCardTitle); title != nil {
+		listing.Title = getTrimmedText(title)
+
+		if href, err := getAttribute(title, "href"); err == nil {
+			listing.Url = absoluteUrl(href)
+		}
+	}
+
+	listing.Price = parseListingPrice(card)
+
+	if address := findChild(card, selector.ListingCardAddress); address != nil {
+		listing.Address = getTrimmedText(address)
+	}
+
+	if seller := findChild(card, selector.ListingCardSellerType); seller != nil {
+		listing.SellerType = getTrimmedText(seller)
+	}
+
+	return listing, nil
+}
+
+// returns nightly price from card, machine-readable meta is preferred over displayed text,
+// zero when price is unknown
+func parseListingPrice(card driver.Element) int {
+	if meta := findChild(card, selector.ListingCardPrice); meta != nil {
+		if content, err := getAttribute(meta, "content"); err == nil {
+			if price, err := util.ParsePrice(content); err == nil {
+				return price
+			}
+		}
+	}
+
+	if text := findChild(card, selector.ListingCardPriceText); text != nil {
+		if price, err := util.ParsePrice(getTrimmedText(text)); err == nil {
+			return price
+		}
+	}
+
+	return 0
+}
+
+func absoluteUrl(href string) string {
+	if strings.HasPrefix(href, "/") {
+		return avitoBaseUrl + href
+	}
+
+	return href
+}
+
+// follows pagination collecting listing cards from every result page
+// until the last page or page cap is reached
+func crawlListings(page driver.Page, task *internal.ParsingTask, opts Options, expectedCount int, log log.Logger) (listings []*internal.Listing, stats *internal.CrawlStats, err error) {
+	pageLimit := opts.crawlPageLimit(task.CrawlPageLimit)
+	stats = &internal.CrawlStats{}
+	seen := make(map[int64]bool)
+
+	for {
+		stats.PageCount++
+		pageLog := log.WithField("CrawlPage", stats.PageCount)
+
+		pageLog.Debug("collecting listing cards")
+		pageListings, err := parseListings(page, pageLog)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// promoted offers may be repeated on several pages
+		for _, listing := range pageListings {
+			if seen[listing.AvitoId] {
+				continue
+			}
+
+			seen[listing.AvitoId] = true
+			listings = append(listings, listing)
+		}
+
+		if countElements(page, selector.PaginationNextPageButton) == 0 {
+			stats.IsComplete = true
+			break
+		}
+
+		if pageLimit > 0 && stats.PageCount >= pageLimit {
+			pageLog.WithField("CrawlPageLimit", pageLimit).Warn("crawl page limit reached, stopping")
+			break
+		}
+
+		pageDelay(opts.CrawlPageDelay)
+
+		nextPageButton, err := getElement(page, selector.PaginationNextPageButton)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err = clickAndWaitNavigation(page, nextPageButton); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	stats.ListingCount = len(listings)
+	stats.IsCountMismatch = stats.IsComplete && stats.ListingCount != expectedCount
+
+	log.WithFields(logrus.Fields{
+		"CrawlPageCount":     stats.PageCount,
+		"CrawlListingCount":  stats.ListingCount,
+		"CrawlIsComplete":    stats.IsComplete,
+		"CrawlCountMismatch": stats.IsCountMismatch,
+	}).Info("crawled result pages")
+
+	return listings, stats, nil
+}
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"github.com/csr-ugra/avito-estate-parser/internal"
+	"github.com/csr-ugra/avito-estate-parser/internal/selector"
+	"testing"
+)
+
+func TestCrawlListings(t *testing.T) {
+	tests := []struct {
+		name           string
+		taskPageLimit  int
+		crawlPageLimit int
+		expectedCount  int
+		want           internal.CrawlStats
+	}{
+		{
+			name:          "every page without cap",
+			expectedCount: fixtureFree,
+			want:          internal.CrawlStats{PageCount: 2, ListingCount: 3, IsComplete: true},
+		},
+		{
+			name:           "stopped at global page limit",
+			crawlPageLimit: 1,
+			expectedCount:  fixtureFree,
+			want:           internal.CrawlStats{PageCount: 1, ListingCount: 2},
+		},
+		{
+			name:           "task page limit overrides global one",
+			taskPageLimit:  2,
+			crawlPageLimit: 1,
+			expectedCount:  fixtureFree,
+			want:           internal.CrawlStats{PageCount: 2, ListingCount: 3, IsComplete: true},
+		},
+		{
+			name:          "count mismatch of complete crawl",
+			expectedCount: 5,
+			want:          internal.CrawlStats{PageCount: 2, ListingCount: 3, IsComplete: true, IsCountMismatch: true},
+		},
+		{
+			// listings beyond the cap were not seen, so their count can't be compared
+			name:           "no count mismatch of stopped crawl",
+			crawlPageLimit: 1,
+			expectedCount:  5,
+			want:           internal.CrawlStats{PageCount: 1, ListingCount: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := openFixture(t, listUrl)
+			if err := click(page, selector.SubmitFiltersBtn); err != nil {
+				t.Fatal(err)
+			}
+
+			listings, stats, err := crawlListings(page, crawlTask(t, tt.taskPageLimit), Options{CrawlPageLimit: tt.crawlPageLimit}, tt.expectedCount, testLogger())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if *stats != tt.want {
+				t.Errorf("expected crawl stats %+v, got %+v", tt.want, *stats)
+			}
+			if len(listings) != tt.want.ListingCount {
+				t.Errorf("expected %d listings, got %d", tt.want.ListingCount, len(listings))
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package parser
+
+import "time"
+
+// Options tune how parser runs tasks
+type Options struct {
+	// Concurrency is number of tasks run at once, each in its own browser context
+	Concurrency int
+	// CrawlPageLimit caps how many result pages are visited
+	// for tasks with pagination crawl enabled, pages are not capped when zero
+	CrawlPageLimit int
+	// CrawlPageDelay is a pause before opening next result page
+	CrawlPageDelay time.Duration
+	// TaskDelay is a pause of worker before it takes next task
+	TaskDelay time.Duration
+	// NavigationTimeout limits how long page load may take, no limit when zero
+	NavigationTimeout time.Duration
+	// Retry is global retry policy of failed task attempts, tasks may override parts of it
+	Retry RetryPolicy
+	// MaxBlockCount is number of blocked task attempts after which run is aborted, 0 never aborts
+	MaxBlockCount int
+	// BlockBackoff is pause of every worker after the first blocked attempt of the run,
+	// it doubles with every next one up to BlockBackoffMax, pause is not capped when that is zero
+	BlockBackoff    time.Duration
+	BlockBackoffMax time.Duration
+	// RejectSuspect makes suspect results fail task attempt instead of being stored with the reason
+	RejectSuspect bool
+	// FixtureDir replaces live browser with saved html pages described by fixtures.json
+	FixtureDir string
+	// SnapshotDir is where page html, screenshot, url and console output
+	// of failed task attempts are saved, snapshots are disabled when empty
+	SnapshotDir string
+}
+
+// returns page cap of task crawl, zero stands for no cap
+func (o Options) crawlPageLimit(taskLimit int) int {
+	if taskLimit > 0 {
+		return taskLimit
+	}
+
+	return o.CrawlPageLimit
+}
@@ -5,92 +5,329 @@ import (
 	"errors"
 	"fmt"
 	"github.com/csr-ugra/avito-estate-parser/internal"
+	"github.com/csr-ugra/avito-estate-parser/internal/driver"
 	"github.com/csr-ugra/avito-estate-parser/internal/log"
+	"github.com/csr-ugra/avito-estate-parser/internal/metrics"
+	"github.com/csr-ugra/avito-estate-parser/internal/proxy"
 	"github.com/csr-ugra/avito-estate-parser/internal/selector"
 	"github.com/csr-ugra/avito-estate-parser/internal/util"
-	"github.com/go-rod/rod"
-	"github.com/go-rod/rod/lib/input"
-	"github.com/go-rod/rod/lib/proto"
 	"github.com/sirupsen/logrus"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
-func Start(ctx context.Context, cfg *util.Config, tasks []*internal.ParsingTask) (results []*internal.ParsingTaskResult, err error) {
+// Start runs tasks and returns results of succeeded ones along with every failed attempt,
+// including attempts of tasks that succeeded after retry
+func Start(ctx context.Context, cfg *util.Config, opts Options, tasks []*internal.ParsingTask) (results []*internal.ParsingTaskResult, failures []*internal.ParsingTaskFailure, err error) {
 	logger := *log.GetLogger()
 	results = make([]*internal.ParsingTaskResult, 0, len(tasks))
 
-	browser := getBrowser(cfg.DevtoolsWebsocketUrl.Value)
-	for _, task := range tasks {
-		const maxRetryCount = 3
-
-		taskLogger := logger.WithFields(logrus.Fields{
-			"TaskId":       task.Id,
-			"TargetId":     task.Target.Id,
-			"TargetName":   task.Target.Name,
-			"LocationId":   task.Location.Id,
-			"LocationName": task.Location.Name,
-			"Url":          task.Url,
-			"Description":  task.Description,
-			"DateStart":    task.DateStart.Format(time.DateOnly),
-			"DateEnd":      task.DateEnd.Format(time.DateOnly),
-		})
-
-		attempt := 1
-
-		for attempt <= maxRetryCount {
-			result, err := runTask(browser, task, taskLogger)
-			if err != nil {
-				taskLogger.Error(err)
-				attempt++
+	browser, err := getBrowser(cfg.DevtoolsWebsocketUrl.Value, opts.FixtureDir, opts.NavigationTimeout, cfg.IsStealth())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// snapshots of different runs are kept apart, opts is a copy so caller's value is intact
+	if opts.SnapshotDir != "" {
+		opts.SnapshotDir = filepath.Join(opts.SnapshotDir, log.GetTraceId())
+	}
+
+	workerCount := max(1, min(opts.Concurrency, len(tasks)))
 
-				taskLogger.WithField("ParsingAttempt", attempt).Warn("failed to compete task, trying again")
-				time.Sleep(2 * time.Second)
-				continue
+	// fixture pages are not loaded over network, so proxies are of no use there
+	var pool *proxy.Pool
+	if proxyUrls := cfg.ProxyUrls(); len(proxyUrls) > 0 && opts.FixtureDir == "" {
+		pool, err = proxy.NewPool(proxyUrls, cfg.ProxyCheckUrl.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+		logger.WithFields(logrus.Fields{
+			"ProxyCount": len(proxyUrls),
+			"ProxyMode":  cfg.ProxyMode.Value,
+		}).Info("using {ProxyCount} proxies per {ProxyMode}")
+	}
+	isProxyPerTask := pool != nil && cfg.ProxyMode.Value == util.ProxyModeTask
+
+	// every worker gets its own incognito context so cookies and location
+	// chosen by one task don't leak into another
+	sessions := make([]*session, 0, workerCount)
+	defer func() {
+		for _, s := range sessions {
+			s.close()
+		}
+	}()
+	for i := 0; i < workerCount; i++ {
+		s := newSession(browser, pool, cfg.IsStealth(), cfg.ProxyForwardHost.Value)
+		sessions = append(sessions, s)
+
+		// in per task mode context is opened along with task
+		if isProxyPerTask {
+			continue
+		}
+		if err = s.open(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	logger.WithField("WorkerCount", workerCount).Debug("starting workers")
+
+	backoff := newBlockBackoff(opts)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	taskCh := make(chan *internal.ParsingTask)
+
+	for i, s := range sessions {
+		wg.Add(1)
+		go func(worker int, s *session) {
+			defer wg.Done()
+
+			for task := range taskCh {
+				result, taskFailures := runTaskWithRetry(ctx, s, backoff, task, opts, logger.WithField("Worker", worker))
+				if isProxyPerTask {
+					s.close()
+				}
+
+				mu.Lock()
+				if result != nil {
+					results = append(results, result)
+				}
+				failures = append(failures, taskFailures...)
+				mu.Unlock()
+
+				sleep(ctx, opts.TaskDelay)
 			}
+		}(i+1, s)
+	}
+
+feed:
+	for _, task := range tasks {
+		select {
+		case taskCh <- task:
+		case <-ctx.Done():
+			logger.Warn("run cancelled, skipping remaining tasks")
+			break feed
+		case <-backoff.aborted:
+			logger.Error("run aborted after too many blocks, skipping remaining tasks")
+			break feed
+		}
+	}
 
-			results = append(results, result)
+	close(taskCh)
+	wg.Wait()
+
+	if backoff.isAborted() {
+		return results, failures, ErrTooManyBlocks
+	}
+
+	return results, failures, err
+}
+
+// runs task until it succeeds or retry policy gives up on it, returns nil result in the latter case;
+// every failed attempt is returned as well. Blocked attempt pauses the whole run
+// and makes session switch to another proxy; task is skipped once run is aborted
+func runTaskWithRetry(ctx context.Context, s *session, backoff *blockBackoff, task *internal.ParsingTask, opts Options, logger log.Logger) (*internal.ParsingTaskResult, []*internal.ParsingTaskFailure) {
+	policy := opts.Retry.forTask(task)
+
+	taskLogger := logger.WithFields(logrus.Fields{
+		"TaskId":       task.Id,
+		"TargetId":     task.Target.Id,
+		"TargetName":   task.Target.Name,
+		"LocationId":   task.Location.Id,
+		"LocationName": task.Location.Name,
+		"Url":          task.Url,
+		"Description":  task.Description,
+		"DateStart":    task.DateStart.Format(time.DateOnly),
+		"DateEnd":      task.DateEnd.Format(time.DateOnly),
+	})
+
+	attempt := 1
+	var failures []*internal.ParsingTaskFailure
+	startedAt := time.Now()
+
+	for attempt <= policy.MaxAttempts {
+		if !backoff.wait(ctx) {
 			break
 		}
 
-		time.Sleep(2 * time.Second)
+		if err := s.open(ctx); err != nil {
+			failures = append(failures, internal.NewParsingTaskFailure(task, attempt, err, task.Url, ""))
+			metrics.ObserveAttempt(string(internal.ErrorKindOf(err)))
+			taskLogger.WithError(err).Error("failed to open browser context, giving up on task")
+			break
+		}
+
+		attemptLogger := taskLogger
+		if s.proxy != nil {
+			attemptLogger = taskLogger.WithField("Proxy", s.proxyName())
+		}
+
+		result, failure, err := runTask(s.context, task, opts, attempt, attemptLogger)
+		if err != nil {
+			failure.Proxy = s.proxyName()
+			failures = append(failures, failure)
+			metrics.ObserveAttempt(string(failure.Kind))
+
+			failureLogger := attemptLogger.WithField("ErrorKind", failure.Kind)
+			if failure.SnapshotPath != "" {
+				failureLogger = failureLogger.WithField("SnapshotPath", failure.SnapshotPath)
+			}
+			failureLogger.Error(err)
+
+			if failure.Kind == internal.ErrorKindBlocked {
+				pause, isAborted := backoff.blocked()
+				if isAborted {
+					attemptLogger.Error("too many blocked attempts, aborting run")
+					break
+				}
+				attemptLogger.WithField("Pause", pause.String()).Warn("access is blocked, pausing run for {Pause}")
+
+				if s.proxy != nil {
+					attemptLogger.Warn("proxy {Proxy} is blocked, switching to another one")
+					s.rotate()
+				}
+			}
+
+			if !policy.shouldRetry(attempt, failure.Kind) {
+				break
+			}
+
+			delay := policy.delay(attempt)
+			attempt++
+			taskLogger.WithFields(logrus.Fields{
+				"ParsingAttempt": attempt,
+				"RetryDelay":     delay.Round(time.Millisecond).String(),
+			}).Warn("failed to complete task, trying again in {RetryDelay}")
+			sleep(ctx, delay)
+			continue
+		}
+
+		result.Proxy = s.proxyName()
+		metrics.ObserveAttempt("")
+		metrics.ObserveTaskSuccess(task.Id, task.Location.Name, task.Target.Name, *task.DateStart, *task.DateEnd, time.Since(startedAt),
+			result.EstateFreeCount, result.EstateTotalCount)
+
+		return result, failures
+	}
+
+	// no attempt was made when run got aborted or cancelled before task
+	if len(failures) == 0 {
+		return nil, nil
 	}
 
-	return results, err
+	metrics.ObserveTaskFailure(task.Id, time.Since(startedAt), string(failures[len(failures)-1].Kind))
+
+	return nil, failures
 }
 
-func runTask(browser *rod.Browser, task *internal.ParsingTask, log log.Logger) (result *internal.ParsingTaskResult, err error) {
-	page := browser.MustPage()
+// runs single task attempt, failure describes the attempt whenever err is not nil
+func runTask(browser driver.Browser, task *internal.ParsingTask, opts Options, attempt int, log log.Logger) (result *internal.ParsingTaskResult, failure *internal.ParsingTaskFailure, err error) {
+	if task.ConfigErr != nil {
+		return nil, internal.NewParsingTaskFailure(task, attempt, task.ConfigErr, task.Url, ""), task.ConfigErr
+	}
+
+	page, err := browser.NewPage()
+	if err != nil {
+		err = fmt.Errorf("failed to open page: %v", err)
+		return nil, internal.NewParsingTaskFailure(task, attempt, err, task.Url, ""), err
+	}
 	// ignoring error explicitly since we don't really care
-	defer func(page *rod.Page) {
+	defer func(page driver.Page) {
 		_ = page.Close()
 	}(page)
 
+	// registered after page close, so runs while page is still open
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		url, urlErr := page.URL()
+		if urlErr != nil {
+			url = task.Url
+		}
+
+		var snapshotPath string
+		if opts.SnapshotDir != "" {
+			var snapshotErr error
+			snapshotPath, snapshotErr = saveFailureSnapshot(page, opts.SnapshotDir, task, attempt, log)
+			if snapshotErr != nil {
+				log.WithError(snapshotErr).Warn("failed to save failure snapshot")
+			}
+		}
+
+		failure = internal.NewParsingTaskFailure(task, attempt, err, url, snapshotPath)
+	}()
+
 	log.Debug("navigating to task url")
-	waitNetwork := page.WaitNavigation(proto.PageLifecycleEventNameNetworkIdle)
-	err = page.Navigate(task.Url)
+	if err = openUrl(page, task.Url, log); err != nil {
+		return nil, nil, err
+	}
+
+	result, err = parsePage(page, task, opts, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = validateResult(result, opts, log); err != nil {
+		return nil, nil, err
+	}
+
+	return result, nil, nil
+}
+
+// fails on invalid result, suspect one is marked with the reason unless suspect results are rejected
+func validateResult(result *internal.ParsingTaskResult, opts Options, log log.Logger) error {
+	err := internal.ValidateResult(result)
+
+	var invalidErr *internal.InvalidResultError
+	if !errors.As(err, &invalidErr) || !invalidErr.Suspect || opts.RejectSuspect {
+		return err
+	}
+
+	log.WithField("SuspectReason", invalidErr.Reason).Warn("result is suspect: {SuspectReason}")
+	result.SuspectReason = invalidErr.Reason
+
+	return nil
+}
+
+// navigates to url, waits for page to load and closes popups that may cover it
+func openUrl(page driver.Page, url string, log log.Logger) error {
+	waitNetwork := page.WaitNavigation()
+	err := page.Navigate(url)
+	if errors.Is(err, driver.ErrNavigationTimeout) {
+		return internal.NewNavigationTimeoutError(url)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to navigate to %s: %v", task.Url, err)
+		return fmt.Errorf("failed to navigate to %s: %v", url, err)
 	}
 
 	log.Debug("waiting for network idle")
-	waitNetwork()
+	if err = navigationError(page, waitNetwork()); err != nil {
+		return err
+	}
 
 	log.Debug("closing popups just in case")
 	for i := 0; i < 3; i++ {
-		if err = page.KeyActions().Press(input.Escape).Do(); err != nil {
+		if err = page.Press(driver.KeyEscape); err != nil {
 			log.Warn("failed to dispatch 'escape' keydown event")
 		}
 
-		time.Sleep(500 * time.Millisecond)
+		pageDelay(500 * time.Millisecond)
 	}
 
-	return parsePage(page, task, log)
+	return nil
 }
 
 // checks if page title is expected for given task and parses counts from page
 // if not tries to navigate to target page and parse it
-func parsePage(page *rod.Page, task *internal.ParsingTask, log log.Logger) (result *internal.ParsingTaskResult, err error) {
+func parsePage(page driver.Page, task *internal.ParsingTask, opts Options, log log.Logger) (result *internal.ParsingTaskResult, err error) {
+	if err = checkBlocked(page); err != nil {
+		return nil, err
+	}
+
 	pageTitle, err := getText(page, selector.PageTitleText)
 	if err != nil {
 		return nil, fmt.Errorf("error getting page title: %w", err)
@@ -99,7 +336,7 @@ func parsePage(page *rod.Page, task *internal.ParsingTask, log log.Logger) (resu
 	isEstateListPage := util.Normalize(pageTitle) == util.Normalize(task.ValidateTitle)
 	if isEstateListPage {
 		log.Debug("page title matches expected")
-		return parseEstateListPage(page, task, log)
+		return parseEstateListPage(page, task, opts, log)
 	}
 
 	log.WithFields(logrus.Fields{
@@ -114,7 +351,11 @@ func parsePage(page *rod.Page, task *internal.ParsingTask, log log.Logger) (resu
 			return nil, fmt.Errorf("error navigating to target page: %w", err)
 		}
 
-		return parseEstateListPage(page, task, log)
+		if err = checkTitle(page, task); err != nil {
+			return nil, err
+		}
+
+		return parseEstateListPage(page, task, opts, log)
 	}
 
 	if strings.HasPrefix(pageTitle, "Жильё посуточно") {
@@ -124,20 +365,80 @@ func parsePage(page *rod.Page, task *internal.ParsingTask, log log.Logger) (resu
 			return nil, fmt.Errorf("error navigating to target page: %w", err)
 		}
 
-		return parseEstateListPage(page, task, log)
+		if err = checkTitle(page, task); err != nil {
+			return nil, err
+		}
+
+		return parseEstateListPage(page, task, opts, log)
+	}
+
+	return nil, internal.NewUnknownPageError(pageTitle)
+}
+
+// checks that widget navigation ended up on estate list page of the task
+func checkTitle(page driver.Page, task *internal.ParsingTask) error {
+	pageTitle, err := getText(page, selector.PageTitleText)
+	if err != nil {
+		return fmt.Errorf("error getting page title: %w", err)
+	}
+
+	if util.Normalize(pageTitle) != util.Normalize(task.ValidateTitle) {
+		// block may be served on any navigation, not only on the first one
+		if err = checkBlocked(page); err != nil {
+			return err
+		}
+
+		return internal.NewTitleMismatchError(task.ValidateTitle, pageTitle)
 	}
 
-	return nil, fmt.Errorf("current page is unknown, can't navigate; page title is %q", pageTitle)
+	return nil
 }
 
-func parseEstateListPage(page *rod.Page, task *internal.ParsingTask, log log.Logger) (result *internal.ParsingTaskResult, err error) {
+// title of page avito serves instead of requested one when access from ip is restricted
+const blockedPageTitle = "Доступ ограничен"
+
+// returns blocked error when avito served block or captcha page instead of requested one
+func checkBlocked(page driver.Page) error {
+	reason := ""
+	switch {
+	case countElements(page, selector.Captcha) > 0:
+		reason = "captcha"
+	case countElements(page, selector.FirewallContainer) > 0:
+		reason = "firewall page"
+	}
+
+	for _, sel := range []selector.Selector{selector.FirewallTitle, selector.PageTitleText} {
+		title, err := getText(page, sel)
+		if err == nil && strings.HasPrefix(util.Normalize(title), util.Normalize(blockedPageTitle)) {
+			reason = strings.Join(strings.Fields(title), " ")
+			break
+		}
+	}
+
+	if reason == "" {
+		return nil
+	}
+
+	url, err := page.URL()
+	if err != nil {
+		url = "<unknown>"
+	}
+
+	return internal.NewBlockedError(url, reason)
+}
+
+func parseEstateListPage(page driver.Page, task *internal.ParsingTask, opts Options, log log.Logger) (result *internal.ParsingTaskResult, err error) {
 	// get total estate objects count
 	// since it's the first visit, there is should be no filters applied,
 	// therefore count at the top of the page is total available estate objects
 	log.Debug("getting estate objects count from title")
 	estateObjectsCountTotal, err := getCountFromHeader(page)
+	if errors.Is(err, &internal.ElementNotFoundError{}) && countElements(page, selector.EmptyResults) > 0 {
+		log.Info("page reports there are no results")
+		return &internal.ParsingTaskResult{Task: task, IsNoResults: true}, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get total estate count: %v", err)
+		return nil, fmt.Errorf("failed to get total estate count: %w", err)
 	}
 
 	// select dates on calendar if calendar is present
@@ -145,30 +446,38 @@ func parseEstateListPage(page *rod.Page, task *internal.ParsingTask, log log.Log
 	btnDayStartSelector := selector.CalendarBtn(task.DateStart)
 	btnDayEndSelector := selector.CalendarBtn(task.DateEnd)
 	buttons := []selector.Selector{btnDayStartSelector, btnDayEndSelector}
+	isDateFilterApplied := true
 	for _, sel := range buttons {
 		log.Debug("clicking calendar")
 		err = click(page, sel)
 
+		// counts read after any failed click are not filtered by dates
 		if errors.Is(err, &internal.ElementNotFoundError{}) {
 			log.WithField("Selector", sel).Warn("calendar button not found, skipping click")
+			isDateFilterApplied = false
+			continue
+		}
+		if err != nil {
+			log.WithError(err).WithField("Selector", sel).Warn("failed to click calendar button, skipping click")
+			isDateFilterApplied = false
 			continue
 		}
 
 		// wait for changes to reflect
-		time.Sleep(2 * time.Second)
+		pageDelay(2 * time.Second)
 	}
 
 	log.Debug("clicking submit filters")
 	err = click(page, selector.SubmitFiltersBtn)
 	if err != nil {
-		return nil, fmt.Errorf("failed to submit filters: %v", err)
+		return nil, fmt.Errorf("failed to submit filters: %w", err)
 	}
-	time.Sleep(2 * time.Second)
+	pageDelay(2 * time.Second)
 
 	log.Debug("getting estate objects count from title")
 	estateObjectsCountFree, err := getCountFromHeader(page)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get free estate count: %v", err)
+		return nil, fmt.Errorf("failed to get free estate count: %w", err)
 	}
 	log.WithFields(logrus.Fields{
 		"FreeCount":  estateObjectsCountFree,
@@ -176,9 +485,33 @@ func parseEstateListPage(page *rod.Page, task *internal.ParsingTask, log log.Log
 	}).Info("got counts of estate objects: {FreeCount}/{TotalCount}")
 
 	result = &internal.ParsingTaskResult{
-		Task:             task,
-		EstateTotalCount: estateObjectsCountTotal,
-		EstateFreeCount:  estateObjectsCountFree,
+		Task:                task,
+		EstateTotalCount:    estateObjectsCountTotal,
+		EstateFreeCount:     estateObjectsCountFree,
+		IsDateFilterApplied: isDateFilterApplied,
+	}
+
+	if task.CrawlPages {
+		log.Debug("crawling result pages")
+		result.Listings, result.Crawl, err = crawlListings(page, task, opts, estateObjectsCountFree, log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to crawl result pages: %w", err)
+		}
+	} else {
+		log.Debug("collecting listing cards")
+		result.Listings, err = parseListings(page, log)
+		if err != nil {
+			return nil, err
+		}
+		log.WithField("ListingCount", len(result.Listings)).Debug("collected listing cards")
+	}
+
+	result.Prices = internal.NewPriceStats(result.Listings)
+	if result.Prices != nil {
+		log.WithFields(logrus.Fields{
+			"PriceMedian":      result.Prices.Median,
+			"PriceSampleCount": result.Prices.SampleCount,
+		}).Info("calculated nightly price stats")
 	}
 
 	return result, nil
@@ -186,7 +519,7 @@ func parseEstateListPage(page *rod.Page, task *internal.ParsingTask, log log.Log
 
 // navigate from estate daily rent widget,
 // eg. from https://www.avito.ru/hanty-mansiyskiy_ao/doma_dachi_kottedzhi/sdam/posutochno-ASgBAgICAkSUA9IQoAjKVQ
-func tryNavigateFromDailyRentWidget(page *rod.Page, task *internal.ParsingTask, log log.Logger) (err error) {
+func tryNavigateFromDailyRentWidget(page driver.Page, task *internal.ParsingTask, log log.Logger) (err error) {
 	err = checkLocation(page, task, log)
 	if err != nil {
 		return err
@@ -198,65 +531,82 @@ func tryNavigateFromDailyRentWidget(page *rod.Page, task *internal.ParsingTask,
 		return err
 	}
 
-	clickElement(calendarButton)
+	if err = clickElement(calendarButton); err != nil {
+		return err
+	}
 
-	time.Sleep(time.Second)
+	pageDelay(time.Second)
 
 	for _, date := range []*time.Time{task.DateStart, task.DateEnd} {
-		// check if calendar month is the same as task date or need to change
-		calendarMonth, err := getText(page, selector.DailyRentWidgetPageCalendarTitle)
-		if err != nil {
-			return err
-		}
-		if !strings.HasPrefix(calendarMonth, util.MonthString(*date)) {
+		// check if calendar month is the same as task date or need to change,
+		// sweep windows may lay several months ahead
+		const maxMonthSwitchCount = 12
+		for i := 0; ; i++ {
+			calendarMonth, err := getText(page, selector.DailyRentWidgetPageCalendarTitle)
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(calendarMonth, util.MonthString(*date)) {
+				break
+			}
+			if i == maxMonthSwitchCount {
+				return fmt.Errorf("calendar month %q not reached", util.MonthString(*date))
+			}
+
 			calendarNextMonthButton, err := getElement(page, selector.DailyRentWidgetPageCalendarNextMonthButton)
 			if err != nil {
 				return err
 			}
 
-			clickElement(calendarNextMonthButton)
+			if err = clickElement(calendarNextMonthButton); err != nil {
+				return err
+			}
 		}
 
 		calendarDateStartButton, err := getElement(page, selector.DailyRentWidgetPageCalendarDayButton(date))
 		if err != nil {
 			return err
 		}
-		clickElement(calendarDateStartButton)
-		time.Sleep(time.Second)
+		if err = clickElement(calendarDateStartButton); err != nil {
+			return err
+		}
+		pageDelay(time.Second)
 	}
 
-	time.Sleep(time.Second)
+	pageDelay(time.Second)
 
 	submitButton, err := tryGetElement(page, selector.WidgetSubmitButton, 3, time.Second/2)
 	if err != nil {
 		return err
 	}
 
-	waitNetwork := page.WaitNavigation(proto.PageLifecycleEventNameNetworkIdle)
-	clickElement(submitButton)
-	waitNetwork()
+	if err = clickAndWaitNavigation(page, submitButton); err != nil {
+		return err
+	}
 
 	calendarResetButton, err := tryGetElement(page, selector.FilterCalendarResetButton, 3, time.Second/2)
 	if err != nil {
-		if errors.Is(err, internal.ElementNotFoundError{}) {
+		if errors.Is(err, &internal.ElementNotFoundError{}) {
 			return nil
 		}
 		return err
 	}
-	clickElement(calendarResetButton)
+	if err = clickElement(calendarResetButton); err != nil {
+		return err
+	}
 
 	err = click(page, selector.SubmitFiltersBtn)
 	if err != nil {
-		return fmt.Errorf("failed to submit filters: %v", err)
+		return fmt.Errorf("failed to submit filters: %w", err)
 	}
-	time.Sleep(2 * time.Second)
+	pageDelay(2 * time.Second)
 
 	return nil
 }
 
 // navigate from base estate page,
 // eg. from https://www.avito.ru/hanty-mansiyskiy_ao/nedvizhimost
-func tryNavigateFromBaseEstateWidget(page *rod.Page, task *internal.ParsingTask, log log.Logger) (err error) {
+func tryNavigateFromBaseEstateWidget(page driver.Page, task *internal.ParsingTask, log log.Logger) (err error) {
 	err = checkLocation(page, task, log)
 	if err != nil {
 		return err
@@ -269,14 +619,16 @@ func tryNavigateFromBaseEstateWidget(page *rod.Page, task *internal.ParsingTask,
 		return err
 	}
 
-	clickElement(estateTypeButton)
+	if err = clickElement(estateTypeButton); err != nil {
+		return err
+	}
 
 	estateTypeListWrapper, err := getElement(page, selector.BaseEstateWidgetTypeFilterDropdown)
 	if err != nil {
 		return err
 	}
 
-	estateTypeList, err := estateTypeListWrapper.MustElement("div").Elements("div")
+	estateTypeList, err := getDropdownItems(estateTypeListWrapper)
 	if err != nil {
 		return err
 	}
@@ -294,12 +646,14 @@ func tryNavigateFromBaseEstateWidget(page *rod.Page, task *internal.ParsingTask,
 
 		if util.Normalize(text) == util.Normalize(task.Target.FilterText) {
 			isTargetFilterFound = true
-			clickElement(el)
+			if err = clickElement(el); err != nil {
+				return err
+			}
 		}
 	}
 
 	if !isTargetFilterFound {
-		return fmt.Errorf("target filter %q not found", task.Target.FilterText)
+		return internal.NewTargetFilterNotFoundError(task.Target.FilterText)
 	}
 
 	log.Info("setting target action to rent")
@@ -308,14 +662,16 @@ func tryNavigateFromBaseEstateWidget(page *rod.Page, task *internal.ParsingTask,
 		return err
 	}
 
-	clickElement(actionButton)
+	if err = clickElement(actionButton); err != nil {
+		return err
+	}
 
 	estateActionListWrapper, err := getElement(page, selector.BaseEstateWidgetTypeFilterDropdown)
 	if err != nil {
 		return err
 	}
 
-	estateActionList, err := estateActionListWrapper.MustElement("div").Elements("div")
+	estateActionList, err := getDropdownItems(estateActionListWrapper)
 	if err != nil {
 		return err
 	}
@@ -333,7 +689,9 @@ func tryNavigateFromBaseEstateWidget(page *rod.Page, task *internal.ParsingTask,
 
 		if util.Normalize(text) == util.Normalize("Снять") {
 			isActionFound = true
-			clickElement(el)
+			if err = clickElement(el); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -347,21 +705,23 @@ func tryNavigateFromBaseEstateWidget(page *rod.Page, task *internal.ParsingTask,
 		return err
 	}
 
-	clickElement(durationButton)
+	if err = clickElement(durationButton); err != nil {
+		return err
+	}
 
 	submitButton, err := getElement(page, selector.WidgetSubmitButton)
 	if err != nil {
 		return err
 	}
 
-	waitNetwork := page.WaitNavigation(proto.PageLifecycleEventNameNetworkIdle)
-	clickElement(submitButton)
-	waitNetwork()
+	if err = clickAndWaitNavigation(page, submitButton); err != nil {
+		return err
+	}
 
 	return err
 }
 
-func checkLocation(page *rod.Page, task *internal.ParsingTask, log log.Logger) error {
+func checkLocation(page driver.Page, task *internal.ParsingTask, log log.Logger) error {
 	log.Debug("checking location")
 	locationButton, err := getElement(page, selector.LocationChangeButton)
 	if err != nil {
@@ -383,7 +743,7 @@ func checkLocation(page *rod.Page, task *internal.ParsingTask, log log.Logger) e
 		"LocationActual":   location,
 	}).Info("location does not match expected, changing...")
 
-	err = changeLocation(page, task.Location.Name)
+	err = changeLocation(page, task.Location.Name, log)
 	if err != nil {
 		return fmt.Errorf("error changing location: %w", err)
 	}
@@ -391,16 +751,92 @@ func checkLocation(page *rod.Page, task *internal.ParsingTask, log log.Logger) e
 	return nil
 }
 
-func changeLocation(page *rod.Page, targetLocation string) (err error) {
-	// todo: change location
-	return errors.New("not implemented")
+// changes search location through location popup,
+// suggestion with the same name is preferred over the one starting with it
+func changeLocation(page driver.Page, targetLocation string, log log.Logger) (err error) {
+	locationButton, err := getElement(page, selector.LocationChangeButton)
+	if err != nil {
+		return err
+	}
+
+	if err = clickElement(locationButton); err != nil {
+		return err
+	}
+
+	input, err := tryGetElement(page, selector.LocationPopupInput, 3, time.Second/2)
+	if err != nil {
+		return err
+	}
+
+	log.WithField("Location", targetLocation).Debug("typing location name")
+	if err = input.Input(targetLocation); err != nil {
+		return fmt.Errorf("failed to type location name: %v", err)
+	}
+
+	// wait for suggestions to load
+	_, err = tryGetElement(page, selector.LocationPopupSuggestion, 5, time.Second)
+	if err != nil {
+		return internal.NewLocationNotFoundError(targetLocation, nil)
+	}
+
+	suggestions, err := page.Elements(selector.LocationPopupSuggestion)
+	if err != nil {
+		return err
+	}
+
+	var match driver.Element
+	suggestionNames := make([]string, 0, len(suggestions))
+	for _, el := range suggestions {
+		name := getTrimmedText(el)
+		suggestionNames = append(suggestionNames, name)
+
+		if util.Normalize(name) == util.Normalize(targetLocation) {
+			match = el
+			break
+		}
+
+		if match == nil && strings.HasPrefix(util.Normalize(name), util.Normalize(targetLocation)) {
+			match = el
+		}
+	}
+
+	if match == nil {
+		return internal.NewLocationNotFoundError(targetLocation, suggestionNames)
+	}
+
+	log.WithField("Suggestion", getTrimmedText(match)).Debug("picking location suggestion")
+	if err = clickElement(match); err != nil {
+		return err
+	}
+
+	saveButton, err := tryGetElement(page, selector.LocationPopupSaveButton, 3, time.Second/2)
+	if err != nil {
+		return err
+	}
+
+	if err = clickAndWaitNavigation(page, saveButton); err != nil {
+		return err
+	}
+
+	location, err := getText(page, selector.LocationChangeButton)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(util.Normalize(location), util.Normalize(targetLocation)) {
+		return internal.NewLocationMismatchError(targetLocation, location)
+	}
+
+	log.WithField("Location", location).Info("location changed")
+
+	return nil
 }
 
-func getCountFromHeader(page *rod.Page) (count int, err error) {
+func getCountFromHeader(page driver.Page) (count int, err error) {
 	el, err := getElement(page, selector.PageTitleCount)
 	if err != nil {
 		return 0, err
 	}
 
-	return getInt(el)
+	return getInt(el, selector.PageTitleCount)
 }
@@ -0,0 +1,358 @@
+package parser
+
+import (
+	"errors"
+	"github.com/csr-ugra/avito-estate-parser/internal"
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
+	"github.com/csr-ugra/avito-estate-parser/internal/driver"
+	"github.com/csr-ugra/avito-estate-parser/internal/log"
+	"github.com/sirupsen/logrus"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// fixture pages are switched synchronously, there is nothing to wait for
+	pageDelay = func(time.Duration) {}
+
+	os.Exit(m.Run())
+}
+
+func testLogger() log.Logger {
+	logger := logrus.New()
+	logger.Out = io.Discard
+
+	return logrus.NewEntry(logger)
+}
+
+// opens page of fixture browser at given url of testdata/fixtures.json
+func openFixture(t *testing.T, url string) driver.Page {
+	t.Helper()
+
+	fixtures, err := driver.LoadFixtures("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := driver.NewFixtureBrowser(fixtures).NewPage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = page.Close()
+	})
+
+	if err = page.Navigate(url); err != nil {
+		t.Fatal(err)
+	}
+
+	return page
+}
+
+// builds task of location Сургут, dates are in yyyy-mm-dd format
+func testTask(t *testing.T, validateTitle string, filterText string, dateStart string, dateEnd string) *internal.ParsingTask {
+	t.Helper()
+
+	start, err := time.Parse(time.DateOnly, dateStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	end, err := time.Parse(time.DateOnly, dateEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	location := &db.EstateLocationModel{Id: 1, Name: "Сургут", UrlPart: "surgut"}
+	target := &db.EstateTargetModel{Id: 1, Name: "Квартиры посуточно", UrlPart: "kvartiry/sdam/posutochno", FilterText: filterText}
+	model := &db.EstateParsingTaskModel{Id: 1, EstateLocationId: 1, EstateTargetId: 1, ValidateTitle: validateTitle}
+
+	task, err := internal.NewParsingTask(model, []*db.EstateLocationModel{location}, []*db.EstateTargetModel{target}, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return task
+}
+
+const (
+	listUrl        = "https://www.avito.ru/surgut/kvartiry/sdam/posutochno"
+	listTitle      = "Квартиры посуточно в Сургуте"
+	emptyListUrl   = "https://www.avito.ru/kogalym/doma_dachi_kottedzhi/sdam/posutochno"
+	emptyListTitle = "Дома посуточно в Когалыме"
+	baseEstateUrl  = "https://www.avito.ru/surgut/nedvizhimost"
+	dailyRentUrl   = "https://www.avito.ru/surgut/posutochno"
+	blockedUrl     = "https://www.avito.ru/surgut/blocked"
+	unknownPageUrl = "https://www.avito.ru/surgut"
+	noCalendarUrl  = "https://www.avito.ru/surgut/kvartiry/sdam/posutochno/no-calendar"
+	fixtureTotal   = 1204
+	fixtureFree    = 3
+)
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		task      *internal.ParsingTask
+		wantErr   error
+		wantTotal int
+		wantFree  int
+		noResults bool
+	}{
+		{
+			name:      "estate list page",
+			url:       listUrl,
+			task:      testTask(t, listTitle, "Квартиры", "2026-11-10", "2026-11-12"),
+			wantTotal: fixtureTotal,
+			wantFree:  fixtureFree,
+		},
+		{
+			name:      "estate list page without results",
+			url:       emptyListUrl,
+			task:      testTask(t, emptyListTitle, "Дома, дачи, коттеджи", "2026-11-10", "2026-11-12"),
+			noResults: true,
+		},
+		{
+			name:      "base estate widget",
+			url:       baseEstateUrl,
+			task:      testTask(t, listTitle, "Квартиры", "2026-11-10", "2026-11-12"),
+			wantTotal: fixtureTotal,
+			wantFree:  fixtureFree,
+		},
+		{
+			name:    "base estate widget without target filter",
+			url:     baseEstateUrl,
+			task:    testTask(t, listTitle, "Гаражи и машиноместа", "2026-11-10", "2026-11-12"),
+			wantErr: &internal.TargetFilterNotFoundError{},
+		},
+		{
+			name:      "daily rent widget",
+			url:       dailyRentUrl,
+			task:      testTask(t, listTitle, "Квартиры", "2026-10-20", "2026-10-22"),
+			wantTotal: fixtureTotal,
+			wantFree:  fixtureFree,
+		},
+		{
+			name:      "daily rent widget switching to next month",
+			url:       dailyRentUrl,
+			task:      testTask(t, listTitle, "Квартиры", "2026-11-10", "2026-11-12"),
+			wantTotal: fixtureTotal,
+			wantFree:  fixtureFree,
+		},
+		{
+			name:    "blocked page",
+			url:     blockedUrl,
+			task:    testTask(t, listTitle, "Квартиры", "2026-11-10", "2026-11-12"),
+			wantErr: &internal.BlockedError{},
+		},
+		{
+			name:    "unknown page",
+			url:     unknownPageUrl,
+			task:    testTask(t, listTitle, "Квартиры", "2026-11-10", "2026-11-12"),
+			wantErr: &internal.UnknownPageError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := openFixture(t, tt.url)
+
+			result, err := parsePage(page, tt.task, Options{}, testLogger())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %T, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.IsNoResults != tt.noResults {
+				t.Errorf("expected IsNoResults %v, got %v", tt.noResults, result.IsNoResults)
+			}
+			if result.EstateTotalCount != tt.wantTotal || result.EstateFreeCount != tt.wantFree {
+				t.Errorf("expected counts %d/%d, got %d/%d",
+					tt.wantFree, tt.wantTotal, result.EstateFreeCount, result.EstateTotalCount)
+			}
+		})
+	}
+}
+
+func TestParseEstateListPage(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		task           *internal.ParsingTask
+		crawlPageLimit int
+		want           internal.ParsingTaskResult
+		wantListingIds []int64
+		// result is kept, but marked suspect when its counts are doubtful
+		wantSuspect bool
+	}{
+		{
+			name: "dates applied",
+			url:  listUrl,
+			task: testTask(t, listTitle, "Квартиры", "2026-11-10", "2026-11-12"),
+			want: internal.ParsingTaskResult{
+				EstateTotalCount:    fixtureTotal,
+				EstateFreeCount:     fixtureFree,
+				IsDateFilterApplied: true,
+			},
+			wantListingIds: []int64{4012345678, 4023456789},
+		},
+		{
+			name: "calendar day missing",
+			url:  listUrl,
+			task: testTask(t, listTitle, "Квартиры", "2026-11-12", "2026-11-14"),
+			want: internal.ParsingTaskResult{
+				EstateTotalCount: fixtureTotal,
+				EstateFreeCount:  fixtureFree,
+			},
+			wantListingIds: []int64{4012345678, 4023456789},
+			wantSuspect:    true,
+		},
+		{
+			name: "calendar missing",
+			url:  noCalendarUrl,
+			task: testTask(t, listTitle, "Квартиры", "2026-11-10", "2026-11-12"),
+			want: internal.ParsingTaskResult{
+				EstateTotalCount: fixtureTotal,
+				EstateFreeCount:  fixtureFree,
+			},
+			wantListingIds: []int64{4012345678, 4023456789},
+			wantSuspect:    true,
+		},
+		{
+			name: "no results",
+			url:  emptyListUrl,
+			task: testTask(t, emptyListTitle, "Дома, дачи, коттеджи", "2026-11-10", "2026-11-12"),
+			want: internal.ParsingTaskResult{IsNoResults: true},
+		},
+		{
+			name:           "crawl of every page",
+			url:            listUrl,
+			task:           crawlTask(t, 0),
+			crawlPageLimit: 10,
+			want: internal.ParsingTaskResult{
+				EstateTotalCount:    fixtureTotal,
+				EstateFreeCount:     fixtureFree,
+				IsDateFilterApplied: true,
+				Crawl:               &internal.CrawlStats{PageCount: 2, ListingCount: 3, IsComplete: true},
+			},
+			wantListingIds: []int64{4012345678, 4023456789, 4034567890},
+		},
+		{
+			name:           "crawl stopped at task page limit",
+			url:            listUrl,
+			task:           crawlTask(t, 1),
+			crawlPageLimit: 10,
+			want: internal.ParsingTaskResult{
+				EstateTotalCount:    fixtureTotal,
+				EstateFreeCount:     fixtureFree,
+				IsDateFilterApplied: true,
+				Crawl:               &internal.CrawlStats{PageCount: 1, ListingCount: 2},
+			},
+			wantListingIds: []int64{4012345678, 4023456789},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := openFixture(t, tt.url)
+
+			result, err := parseEstateListPage(page, tt.task, Options{CrawlPageLimit: tt.crawlPageLimit}, testLogger())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.EstateTotalCount != tt.want.EstateTotalCount || result.EstateFreeCount != tt.want.EstateFreeCount {
+				t.Errorf("expected counts %d/%d, got %d/%d",
+					tt.want.EstateFreeCount, tt.want.EstateTotalCount, result.EstateFreeCount, result.EstateTotalCount)
+			}
+			if result.IsNoResults != tt.want.IsNoResults {
+				t.Errorf("expected IsNoResults %v, got %v", tt.want.IsNoResults, result.IsNoResults)
+			}
+			if result.IsDateFilterApplied != tt.want.IsDateFilterApplied {
+				t.Errorf("expected IsDateFilterApplied %v, got %v", tt.want.IsDateFilterApplied, result.IsDateFilterApplied)
+			}
+
+			if err := validateResult(result, Options{}, testLogger()); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			if (result.SuspectReason != "") != tt.wantSuspect {
+				t.Errorf("expected suspect %v, got reason %q", tt.wantSuspect, result.SuspectReason)
+			}
+
+			if tt.want.Crawl == nil && result.Crawl != nil {
+				t.Errorf("expected no crawl stats, got %+v", *result.Crawl)
+			}
+			if tt.want.Crawl != nil && (result.Crawl == nil || *result.Crawl != *tt.want.Crawl) {
+				t.Errorf("expected crawl stats %+v, got %+v", *tt.want.Crawl, result.Crawl)
+			}
+
+			ids := make([]int64, 0, len(result.Listings))
+			for _, listing := range result.Listings {
+				ids = append(ids, listing.AvitoId)
+			}
+			if len(ids) != len(tt.wantListingIds) {
+				t.Fatalf("expected listings %v, got %v", tt.wantListingIds, ids)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantListingIds[i] {
+					t.Fatalf("expected listings %v, got %v", tt.wantListingIds, ids)
+				}
+			}
+		})
+	}
+}
+
+func crawlTask(t *testing.T, pageLimit int) *internal.ParsingTask {
+	task := testTask(t, listTitle, "Квартиры", "2026-11-10", "2026-11-12")
+	task.CrawlPages = true
+	task.CrawlPageLimit = pageLimit
+
+	return task
+}
+
+func TestParseListings(t *testing.T) {
+	page := openFixture(t, listUrl)
+	if err := click(page, "button[data-marker=\"search-filters/submit-button\"]"); err != nil {
+		t.Fatal(err)
+	}
+
+	listings, err := parseListings(page, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// card without numeric id is skipped
+	want := []internal.Listing{
+		{
+			AvitoId:    4012345678,
+			Title:      "1-к. квартира, 40 м², 5/12 эт.",
+			Price:      2500,
+			Address:    "ул. Мелик-Карамова, 41",
+			Url:        "https://www.avito.ru/surgut/kvartiry/1-k._kvartira_40m_512et._4012345678",
+			SellerType: "Частное лицо",
+		},
+		{
+			AvitoId:    4023456789,
+			Title:      "2-к. квартира, 56 м², 3/9 эт.",
+			Price:      3200,
+			Address:    "пр-т Ленина, 19",
+			Url:        "https://www.avito.ru/surgut/kvartiry/2-k._kvartira_56m_39et._4023456789",
+			SellerType: "Агентство",
+		},
+	}
+
+	if len(listings) != len(want) {
+		t.Fatalf("expected %d listings, got %d", len(want), len(listings))
+	}
+	for i := range want {
+		if *listings[i] != want[i] {
+			t.Errorf("listing %d: expected %+v, got %+v", i, want[i], *listings[i])
+		}
+	}
+}
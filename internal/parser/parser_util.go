@@ -1,18 +1,36 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
 	"github.com/csr-ugra/avito-estate-parser/internal"
+	"github.com/csr-ugra/avito-estate-parser/internal/driver"
 	"github.com/csr-ugra/avito-estate-parser/internal/log"
 	"github.com/csr-ugra/avito-estate-parser/internal/selector"
 	"github.com/csr-ugra/avito-estate-parser/internal/util"
 	"github.com/csr-ugra/avito-estate-parser/internal/util/assert"
 	"github.com/go-rod/rod"
 	"strconv"
+	"strings"
 	"time"
 )
 
-// connect to running browser
-func getBrowser(devtoolsWebsocketUrl string) *rod.Browser {
+// pageDelay gives page time to reflect an action, tests replace it so they don't wait
+var pageDelay = time.Sleep
+
+// connect to running browser, or open saved html pages when fixture directory is set;
+// in stealth mode actions are not slowed down evenly and traced, since both are easy to spot,
+// pages of stealth contexts pause like a person instead
+func getBrowser(devtoolsWebsocketUrl string, fixtureDir string, navigationTimeout time.Duration, stealth bool) (driver.Browser, error) {
+	if fixtureDir != "" {
+		fixtures, err := driver.LoadFixtures(fixtureDir)
+		if err != nil {
+			return nil, err
+		}
+
+		return driver.NewFixtureBrowser(fixtures), nil
+	}
+
 	if devtoolsWebsocketUrl == "" {
 		// since designed to run in docker compose with dedicated browser image,
 		// downloading browser to this container is a stupid decision
@@ -21,15 +39,19 @@ func getBrowser(devtoolsWebsocketUrl string) *rod.Browser {
 		//devtoolsWebsocketUrl = launcher.New().Bin(path).MustLaunch()
 	}
 
-	return rod.New().
-		SlowMotion(1 * time.Second).
-		Trace(true).
-		ControlURL(devtoolsWebsocketUrl).
-		MustConnect()
+	browser := rod.New().ControlURL(devtoolsWebsocketUrl)
+	if !stealth {
+		browser = browser.SlowMotion(1 * time.Second).Trace(true)
+	}
+	if err := browser.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect to browser: %v", err)
+	}
+
+	return driver.NewRodBrowser(browser, navigationTimeout), nil
 }
 
-func getElement(page *rod.Page, sel selector.Selector) (el *rod.Element, err error) {
-	el, err = page.Sleeper(rod.NotFoundSleeper).Element(sel.String())
+func getElement(page driver.Page, sel selector.Selector) (el driver.Element, err error) {
+	el, err = page.Element(sel)
 	if err != nil {
 		return el, internal.NewElementNotFoundError(sel)
 	}
@@ -37,7 +59,7 @@ func getElement(page *rod.Page, sel selector.Selector) (el *rod.Element, err err
 	return el, nil
 }
 
-func tryGetElement(page *rod.Page, sel selector.Selector, maxRetryCount uint, sleepTime time.Duration) (el *rod.Element, err error) {
+func tryGetElement(page driver.Page, sel selector.Selector, maxRetryCount uint, sleepTime time.Duration) (el driver.Element, err error) {
 	for i := uint(0); i < maxRetryCount; i++ {
 		el, err = getElement(page, sel)
 		if err == nil {
@@ -45,15 +67,15 @@ func tryGetElement(page *rod.Page, sel selector.Selector, maxRetryCount uint, sl
 		}
 
 		if i < maxRetryCount-1 {
-			time.Sleep(sleepTime)
+			pageDelay(sleepTime)
 		}
 	}
 
 	return nil, internal.NewElementNotFoundError(sel)
 }
 
-func countElements(page *rod.Page, sel selector.Selector) int {
-	elements, err := page.Elements(sel.String())
+func countElements(page driver.Page, sel selector.Selector) int {
+	elements, err := page.Elements(sel)
 	if err != nil {
 		return 0
 	}
@@ -61,39 +83,73 @@ func countElements(page *rod.Page, sel selector.Selector) int {
 	return len(elements)
 }
 
-func getElementText(el *rod.Element) (string, error) {
+func getElementText(el driver.Element) (string, error) {
 	assert.NotNil(el, "expecting element to get text from to be not nil")
 
 	return el.Text()
 }
 
-func getText(page *rod.Page, sel selector.Selector) (string, error) {
+func getText(page driver.Page, sel selector.Selector) (string, error) {
 	count := countElements(page, sel)
 	if count == 0 {
 		return "", internal.NewElementNotFoundError(sel)
 	}
 
-	el := page.MustElement(sel.String())
+	el, err := getElement(page, sel)
+	if err != nil {
+		return "", err
+	}
+
 	return el.Text()
 }
 
-func clickElement(el *rod.Element) {
+// clicks element, in stealth mode mouse gets there along curved path and clicks after short random pause
+func clickElement(el driver.Element) error {
 	assert.NotNil(el, "expecting element to click to be not nil")
 
-	el.MustClick()
+	return el.Click()
+}
+
+// clicks element that leads to another page and waits for it to load
+func clickAndWaitNavigation(page driver.Page, el driver.Element) error {
+	wait := page.WaitNavigation()
+	if err := clickElement(el); err != nil {
+		return err
+	}
+
+	return navigationError(page, wait())
+}
+
+// replaces driver timeout with typed error carrying url page got stuck on
+func navigationError(page driver.Page, err error) error {
+	if !errors.Is(err, driver.ErrNavigationTimeout) {
+		return err
+	}
+
+	url, urlErr := page.URL()
+	if urlErr != nil {
+		url = "<unknown>"
+	}
+
+	return internal.NewNavigationTimeoutError(url)
 }
 
-func click(page *rod.Page, sel selector.Selector) error {
+func click(page driver.Page, sel selector.Selector) error {
 	count := countElements(page, sel)
 	if count == 0 {
 		return internal.NewElementNotFoundError(sel)
 	}
 
-	page.MustElement(sel.String()).MustClick()
-	return nil
+	el, err := getElement(page, sel)
+	if err != nil {
+		return err
+	}
+
+	return clickElement(el)
 }
 
-func getInt(el *rod.Element) (int, error) {
+// parses integer from element text, selector is only used to describe parse error
+func getInt(el driver.Element, sel selector.Selector) (int, error) {
 	assert.NotNil(el, "expecting element to get int from to be not nil")
 
 	str, err := getElementText(el)
@@ -101,5 +157,57 @@ func getInt(el *rod.Element) (int, error) {
 		return 0, err
 	}
 
-	return strconv.Atoi(util.Normalize(str))
+	count, err := strconv.Atoi(util.Normalize(str))
+	if err != nil {
+		return 0, internal.NewCountParseError(sel, str, err)
+	}
+
+	return count, nil
+}
+
+// returns first child element matching selector or nil, does not wait for element to appear
+func findChild(el driver.Element, sel selector.Selector) driver.Element {
+	assert.NotNil(el, "expecting element to search children in to be not nil")
+
+	elements, err := el.Elements(sel)
+	if err != nil || len(elements) == 0 {
+		return nil
+	}
+
+	return elements[0]
+}
+
+func getAttribute(el driver.Element, name string) (string, error) {
+	assert.NotNil(el, "expecting element to get attribute from to be not nil")
+
+	value, err := el.Attribute(name)
+	if err != nil {
+		return "", err
+	}
+
+	if value == nil {
+		return "", fmt.Errorf("element has no attribute %q", name)
+	}
+
+	return *value, nil
+}
+
+// returns element text with collapsed whitespace, empty string on error
+func getTrimmedText(el driver.Element) string {
+	text, err := getElementText(el)
+	if err != nil {
+		return ""
+	}
+
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// returns options of widget dropdown list
+func getDropdownItems(wrapper driver.Element) ([]driver.Element, error) {
+	list := findChild(wrapper, "div")
+	if list == nil {
+		return nil, internal.NewElementNotFoundError("div")
+	}
+
+	return list.Elements("div")
 }
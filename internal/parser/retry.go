@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"context"
+	"github.com/csr-ugra/avito-estate-parser/internal"
+	"math"
+	"math/rand"
+	"slices"
+	"time"
+)
+
+// DefaultFailFastKinds are errors retrying won't fix: task config is wrong
+// or avito does not offer what task asks for
+var DefaultFailFastKinds = []internal.ErrorKind{
+	internal.ErrorKindConfig,
+	internal.ErrorKindTargetFilterNotFound,
+}
+
+// RetryPolicy decides if failed task attempt is tried again and how long to wait before that
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt too, task is tried once when it is 1 or less
+	MaxAttempts int
+	// BaseDelay is wait before the first retry, it doubles with every next one up to MaxDelay,
+	// delay is not capped when MaxDelay is zero
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jitter is fraction of delay it is randomly changed by, eg. 0.2 gives delay ±20%
+	Jitter float64
+	// FailFastKinds are error kinds task is not retried on
+	FailFastKinds []internal.ErrorKind
+}
+
+// returns policy with overrides of task applied
+func (p RetryPolicy) forTask(task *internal.ParsingTask) RetryPolicy {
+	if task.RetryMaxAttempts > 0 {
+		p.MaxAttempts = task.RetryMaxAttempts
+	}
+
+	if task.RetryBaseDelay > 0 {
+		p.BaseDelay = task.RetryBaseDelay
+		if p.MaxDelay > 0 {
+			p.MaxDelay = max(p.MaxDelay, task.RetryBaseDelay)
+		}
+	}
+
+	p.MaxAttempts = max(1, p.MaxAttempts)
+
+	return p
+}
+
+// returns if attempt that failed with error of given kind should be followed by another one
+func (p RetryPolicy) shouldRetry(attempt int, kind internal.ErrorKind) bool {
+	return attempt < p.MaxAttempts && !slices.Contains(p.FailFastKinds, kind)
+}
+
+// returns wait before retry of failed attempt
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	// without cap doubling still stops short of overflow
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay) && delay < math.MaxInt64/2; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 {
+		delay = min(delay, p.MaxDelay)
+	}
+
+	if p.Jitter > 0 {
+		delay += time.Duration(float64(delay) * p.Jitter * (2*rand.Float64() - 1))
+	}
+
+	return max(0, delay)
+}
+
+// sleeps for given duration, returns early when context is done
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,180 @@
+package parser
+
+import (
+	"github.com/csr-ugra/avito-estate-parser/internal"
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
+	"github.com/sirupsen/logrus"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		// delay after every failed attempt starting with the first one
+		want []time.Duration
+	}{
+		{
+			name:   "doubles up to max",
+			policy: RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second},
+			want:   []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:   "no cap when max is zero",
+			policy: RetryPolicy{BaseDelay: time.Second},
+			want:   []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second},
+		},
+		{
+			name:   "max below base",
+			policy: RetryPolicy{BaseDelay: time.Minute, MaxDelay: time.Second},
+			want:   []time.Duration{time.Second, time.Second},
+		},
+		{
+			name:   "no delay",
+			policy: RetryPolicy{MaxDelay: time.Minute},
+			want:   []time.Duration{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range tt.want {
+				if got := tt.policy.delay(i + 1); got != want {
+					t.Errorf("attempt %d: expected delay %s, got %s", i+1, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelayJitter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 10 * time.Second, MaxDelay: time.Minute, Jitter: 0.2}
+
+	for i := 0; i < 100; i++ {
+		got := policy.delay(1)
+		if got < 8*time.Second || got > 12*time.Second {
+			t.Fatalf("expected delay within 10s±20%%, got %s", got)
+		}
+	}
+}
+
+func TestRetryPolicyDelayDoesNotOverflow(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Hour}
+
+	for attempt := 1; attempt <= 100; attempt++ {
+		if got := policy.delay(attempt); got <= 0 {
+			t.Fatalf("attempt %d: expected positive delay, got %s", attempt, got)
+		}
+	}
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, FailFastKinds: DefaultFailFastKinds}
+
+	tests := []struct {
+		name    string
+		attempt int
+		kind    internal.ErrorKind
+		want    bool
+	}{
+		{name: "first attempt", attempt: 1, kind: internal.ErrorKindNavigationTimeout, want: true},
+		{name: "attempt before last", attempt: 2, kind: internal.ErrorKindBlocked, want: true},
+		{name: "last attempt", attempt: 3, kind: internal.ErrorKindNavigationTimeout, want: false},
+		{name: "config error", attempt: 1, kind: internal.ErrorKindConfig, want: false},
+		{name: "target filter not found", attempt: 1, kind: internal.ErrorKindTargetFilterNotFound, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.shouldRetry(tt.attempt, tt.kind); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyForTask(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: 2 * time.Second, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		task   *internal.ParsingTask
+		want   RetryPolicy
+	}{
+		{
+			name:   "no overrides",
+			policy: policy,
+			task:   &internal.ParsingTask{},
+			want:   policy,
+		},
+		{
+			name:   "task overrides",
+			policy: policy,
+			task:   &internal.ParsingTask{RetryMaxAttempts: 5, RetryBaseDelay: 5 * time.Second},
+			want:   RetryPolicy{MaxAttempts: 5, BaseDelay: 5 * time.Second, MaxDelay: 10 * time.Second},
+		},
+		{
+			name:   "task base delay above max raises max",
+			policy: policy,
+			task:   &internal.ParsingTask{RetryBaseDelay: 30 * time.Second},
+			want:   RetryPolicy{MaxAttempts: 3, BaseDelay: 30 * time.Second, MaxDelay: 30 * time.Second},
+		},
+		{
+			name:   "task base delay keeps no cap",
+			policy: RetryPolicy{MaxAttempts: 3, BaseDelay: 2 * time.Second},
+			task:   &internal.ParsingTask{RetryBaseDelay: 30 * time.Second},
+			want:   RetryPolicy{MaxAttempts: 3, BaseDelay: 30 * time.Second},
+		},
+		{
+			name:   "at least one attempt",
+			policy: RetryPolicy{},
+			task:   &internal.ParsingTask{},
+			want:   RetryPolicy{MaxAttempts: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.policy.forTask(tt.task)
+			if got.MaxAttempts != tt.want.MaxAttempts || got.BaseDelay != tt.want.BaseDelay || got.MaxDelay != tt.want.MaxDelay {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunTaskConfigError(t *testing.T) {
+	// location without url part can't be turned into task url
+	location := &db.EstateLocationModel{Id: 1, Name: "Сургут"}
+	target := &db.EstateTargetModel{Id: 1, Name: "Квартиры посуточно", UrlPart: "kvartiry/sdam/posutochno"}
+	model := &db.EstateParsingTaskModel{Id: 1, EstateLocationId: 1, EstateTargetId: 1}
+
+	task, err := internal.NewParsingTask(model, []*db.EstateLocationModel{location}, []*db.EstateTargetModel{target}, time.Now(), time.Now())
+	if err != nil {
+		t.Fatalf("expected task to be built despite config error, got %v", err)
+	}
+	if task.ConfigErr == nil {
+		t.Fatal("expected task to carry config error")
+	}
+
+	logger := logrus.New()
+	logger.Out = io.Discard
+
+	// page is never opened for misconfigured task, so no browser is needed
+	result, failure, err := runTask(nil, task, Options{}, 1, logrus.NewEntry(logger))
+	if err == nil || result != nil {
+		t.Fatalf("expected task to fail, got result %+v", result)
+	}
+	if failure == nil || failure.Kind != internal.ErrorKindConfig {
+		t.Fatalf("expected failure of kind %s, got %+v", internal.ErrorKindConfig, failure)
+	}
+
+	// config error is not retried, so the rest of the run is not held up by it
+	policy := RetryPolicy{MaxAttempts: 3, FailFastKinds: DefaultFailFastKinds}
+	if policy.shouldRetry(failure.Attempt, failure.Kind) {
+		t.Error("expected config error not to be retried")
+	}
+}
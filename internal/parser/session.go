@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/driver"
+	"github.com/csr-ugra/avito-estate-parser/internal/proxy"
+)
+
+// session is browser context of a worker along with proxy it goes through,
+// context is opened on first use and reopened through another proxy after block
+type session struct {
+	browser driver.Browser
+	// pool is nil when traffic goes out directly
+	pool    *proxy.Pool
+	stealth bool
+	// forwardHost is address forwarder of socks5 proxy with credentials listens on
+	forwardHost string
+	context     driver.BrowserContext
+	proxy       *proxy.Proxy
+	// forwarder is nil unless context goes through socks5 proxy with credentials
+	forwarder *proxy.Forwarder
+}
+
+func newSession(browser driver.Browser, pool *proxy.Pool, stealth bool, forwardHost string) *session {
+	return &session{browser: browser, pool: pool, stealth: stealth, forwardHost: forwardHost}
+}
+
+// open opens browser context through next healthy proxy of pool, does nothing when already open;
+// stealth context gets new random fingerprint every time it is opened
+func (s *session) open(ctx context.Context) error {
+	if s.context != nil {
+		return nil
+	}
+
+	opts := driver.ContextOptions{Stealth: s.stealth}
+	s.proxy = nil
+	if s.pool != nil {
+		p, err := s.pool.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+
+		s.proxy = p
+		opts.Proxy = p.Url
+
+		// browser can't authenticate to socks5 proxy, so it goes through forwarder that does
+		if p.Url.Scheme == "socks5" && p.Url.User != nil {
+			f, err := proxy.NewForwarder(p.Url, s.forwardHost)
+			if err != nil {
+				return err
+			}
+
+			s.forwarder = f
+			opts.Proxy = f.Url()
+		}
+	}
+
+	c, err := s.browser.NewContext(opts)
+	if err != nil {
+		s.closeForwarder()
+		return fmt.Errorf("failed to open browser context: %v", err)
+	}
+
+	s.context = c
+
+	return nil
+}
+
+// rotate takes blocked proxy out of pool and closes context, next open goes through another proxy
+func (s *session) rotate() {
+	if s.pool == nil || s.proxy == nil {
+		return
+	}
+
+	s.pool.MarkBlocked(s.proxy)
+	s.close()
+}
+
+func (s *session) close() {
+	if s.context == nil {
+		return
+	}
+
+	_ = s.context.Close()
+	s.context = nil
+	s.closeForwarder()
+}
+
+func (s *session) closeForwarder() {
+	if s.forwarder == nil {
+		return
+	}
+
+	_ = s.forwarder.Close()
+	s.forwarder = nil
+}
+
+// proxyName is proxy of session without credentials, empty when there is none
+func (s *session) proxyName() string {
+	if s.proxy == nil {
+		return ""
+	}
+
+	return s.proxy.String()
+}
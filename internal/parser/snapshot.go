@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal"
+	"github.com/csr-ugra/avito-estate-parser/internal/driver"
+	"github.com/csr-ugra/avito-estate-parser/internal/log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// saves page state of failed task attempt into
+// <dir>/task-<TaskId>_<DateStart>_<DateEnd>/attempt-<n>, dir is expected to be unique per run,
+// parts that can't be captured are skipped so snapshot is saved as complete as possible
+func saveFailureSnapshot(page driver.Page, dir string, task *internal.ParsingTask, attempt int, log log.Logger) (path string, err error) {
+	path = filepath.Join(dir,
+		fmt.Sprintf("task-%d_%s_%s", task.Id, task.DateStart.Format(time.DateOnly), task.DateEnd.Format(time.DateOnly)),
+		fmt.Sprintf("attempt-%d", attempt))
+
+	if err = os.MkdirAll(path, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %v", err)
+	}
+
+	url, err := page.URL()
+	if err != nil {
+		log.WithError(err).Warn("failed to get page url for snapshot")
+	}
+	if err = os.WriteFile(filepath.Join(path, "url.txt"), []byte(url+"\n"), 0o644); err != nil {
+		return "", err
+	}
+
+	html, err := page.HTML()
+	if err != nil {
+		log.WithError(err).Warn("failed to get page html for snapshot")
+	} else if err = os.WriteFile(filepath.Join(path, "page.html"), []byte(html), 0o644); err != nil {
+		return "", err
+	}
+
+	screenshot, err := page.Screenshot()
+	if err != nil {
+		log.WithError(err).Warn("failed to take screenshot for snapshot")
+	} else if err = os.WriteFile(filepath.Join(path, "screenshot.png"), screenshot, 0o644); err != nil {
+		return "", err
+	}
+
+	console := strings.Join(page.Console(), "\n")
+	if err = os.WriteFile(filepath.Join(path, "console.log"), []byte(console), 0o644); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
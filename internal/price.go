@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"math"
+	"slices"
+)
+
+// PriceStats aggregates nightly prices of free listings for a task date window
+type PriceStats struct {
+	Min    int
+	Max    int
+	Mean   float64
+	Median float64
+	P25    float64
+	P75    float64
+	// SampleCount is number of listings with known price stats cover,
+	// may be less than free count when only first result page is collected
+	SampleCount int
+}
+
+// NewPriceStats calculates price aggregates over listings with known price,
+// returns nil when there are none
+func NewPriceStats(listings []*Listing) *PriceStats {
+	prices := make([]int, 0, len(listings))
+	for _, listing := range listings {
+		if listing.Price > 0 {
+			prices = append(prices, listing.Price)
+		}
+	}
+
+	if len(prices) == 0 {
+		return nil
+	}
+
+	slices.Sort(prices)
+
+	sum := 0
+	for _, price := range prices {
+		sum += price
+	}
+
+	return &PriceStats{
+		Min:         prices[0],
+		Max:         prices[len(prices)-1],
+		Mean:        float64(sum) / float64(len(prices)),
+		Median:      percentile(prices, 0.5),
+		P25:         percentile(prices, 0.25),
+		P75:         percentile(prices, 0.75),
+		SampleCount: len(prices),
+	}
+}
+
+// linear interpolation between closest ranks, sorted must not be empty
+func percentile(sorted []int, p float64) float64 {
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	weight := rank - float64(lower)
+
+	return float64(sorted[lower])*(1-weight) + float64(sorted[upper])*weight
+}
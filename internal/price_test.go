@@ -0,0 +1,68 @@
+package internal
+
+import "testing"
+
+func listingsWithPrices(prices ...int) []*Listing {
+	listings := make([]*Listing, 0, len(prices))
+	for i, price := range prices {
+		listings = append(listings, &Listing{AvitoId: int64(i + 1), Price: price})
+	}
+
+	return listings
+}
+
+func TestNewPriceStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		listings []*Listing
+		want     *PriceStats
+	}{
+		{
+			name:     "no listings",
+			listings: nil,
+			want:     nil,
+		},
+		{
+			name:     "no known prices",
+			listings: listingsWithPrices(0, 0),
+			want:     nil,
+		},
+		{
+			name:     "single price",
+			listings: listingsWithPrices(2500),
+			want:     &PriceStats{Min: 2500, Max: 2500, Mean: 2500, Median: 2500, P25: 2500, P75: 2500, SampleCount: 1},
+		},
+		{
+			name:     "odd count, unsorted",
+			listings: listingsWithPrices(3000, 1000, 2000),
+			want:     &PriceStats{Min: 1000, Max: 3000, Mean: 2000, Median: 2000, P25: 1500, P75: 2500, SampleCount: 3},
+		},
+		{
+			name:     "even count interpolates median",
+			listings: listingsWithPrices(1000, 2000, 3000, 4000),
+			want:     &PriceStats{Min: 1000, Max: 4000, Mean: 2500, Median: 2500, P25: 1750, P75: 3250, SampleCount: 4},
+		},
+		{
+			name:     "unknown prices are left out",
+			listings: listingsWithPrices(0, 1800, 0, 2200),
+			want:     &PriceStats{Min: 1800, Max: 2200, Mean: 2000, Median: 2000, P25: 1900, P75: 2100, SampleCount: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewPriceStats(tt.listings)
+
+			if tt.want == nil || got == nil {
+				if tt.want != got {
+					t.Fatalf("expected %v, got %+v", tt.want, got)
+				}
+				return
+			}
+
+			if *got != *tt.want {
+				t.Errorf("expected %+v, got %+v", *tt.want, *got)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package proxy
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	netproxy "golang.org/x/net/proxy"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// upstream proxy must accept connection within this time
+	forwardDialTimeout = 30 * time.Second
+	// client must finish handshake within this time
+	forwardHandshakeTimeout = 15 * time.Second
+)
+
+// socks5 constants, see RFC 1928
+const (
+	socksVersion        = 0x05
+	socksMethodNoAuth   = 0x00
+	socksNoAcceptable   = 0xff
+	socksCmdConnect     = 0x01
+	socksAtypIPv4       = 0x01
+	socksAtypDomain     = 0x03
+	socksAtypIPv6       = 0x04
+	socksReplySucceeded = 0x00
+	socksReplyFailure   = 0x01
+	socksReplyCmd       = 0x07
+	socksReplyAtyp      = 0x08
+)
+
+// Forwarder is socks5 proxy without credentials that relays every connection through
+// socks5 proxy with credentials, chrome can't authenticate to socks5 proxy so it is given forwarder instead
+type Forwarder struct {
+	listener net.Listener
+	dialer   netproxy.ContextDialer
+	// ctx is cancelled on Close to abort connections being dialed
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewForwarder starts forwarder to upstream socks5 proxy on random port of host,
+// host must be reachable from browser, eg. 127.0.0.1 when browser runs on the same machine
+func NewForwarder(upstream *url.URL, host string) (*Forwarder, error) {
+	if upstream.Scheme != "socks5" {
+		return nil, fmt.Errorf("proxy %s://%s: only socks5 proxy can be forwarded", upstream.Scheme, upstream.Host)
+	}
+
+	var auth *netproxy.Auth
+	if upstream.User != nil {
+		password, _ := upstream.User.Password()
+		auth = &netproxy.Auth{User: upstream.User.Username(), Password: password}
+	}
+
+	dialer, err := netproxy.SOCKS5("tcp", upstream.Host, auth, &net.Dialer{Timeout: forwardDialTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start forwarder of proxy %s://%s: %v", upstream.Scheme, upstream.Host, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &Forwarder{
+		listener: listener,
+		dialer:   dialer.(netproxy.ContextDialer),
+		ctx:      ctx,
+		cancel:   cancel,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	f.wg.Add(1)
+	go f.serve()
+
+	return f, nil
+}
+
+// Url returns address browser should use as proxy, it has no credentials
+func (f *Forwarder) Url() *url.URL {
+	return &url.URL{Scheme: "socks5", Host: f.listener.Addr().String()}
+}
+
+// Close stops accepting connections and closes relayed ones
+func (f *Forwarder) Close() error {
+	f.mu.Lock()
+	f.closed = true
+	for conn := range f.conns {
+		_ = conn.Close()
+	}
+	f.mu.Unlock()
+	f.cancel()
+
+	err := f.listener.Close()
+	f.wg.Wait()
+
+	return err
+}
+
+func (f *Forwarder) serve() {
+	defer f.wg.Done()
+
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			// listener is closed, temporary errors are not returned by tcp listener
+			return
+		}
+
+		if !f.track(conn) {
+			_ = conn.Close()
+			return
+		}
+
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			defer f.untrack(conn)
+
+			f.handle(conn)
+		}()
+	}
+}
+
+// remembers connection to close it along with forwarder, false when forwarder is already closed
+func (f *Forwarder) track(conn net.Conn) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return false
+	}
+	f.conns[conn] = struct{}{}
+
+	return true
+}
+
+func (f *Forwarder) untrack(conn net.Conn) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_ = conn.Close()
+	delete(f.conns, conn)
+}
+
+// serves socks5 handshake of client and relays connection through upstream proxy until either side closes it
+func (f *Forwarder) handle(client net.Conn) {
+	_ = client.SetDeadline(time.Now().Add(forwardHandshakeTimeout))
+
+	addr, err := readConnect(client)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(f.ctx, forwardDialTimeout)
+	upstream, err := f.dialer.DialContext(ctx, "tcp", addr)
+	cancel()
+	if err != nil {
+		_ = writeReply(client, socksReplyFailure)
+		return
+	}
+
+	if !f.track(upstream) {
+		_ = upstream.Close()
+		return
+	}
+	defer f.untrack(upstream)
+
+	if err = writeReply(client, socksReplySucceeded); err != nil {
+		return
+	}
+	_ = client.SetDeadline(time.Time{})
+
+	done := make(chan struct{}, 2)
+	relay := func(dst, src net.Conn) {
+		_, _ = io.Copy(dst, src)
+		done <- struct{}{}
+	}
+	go relay(upstream, client)
+	go relay(client, upstream)
+
+	// closing both sides unblocks the other copy
+	<-done
+	_ = client.Close()
+	_ = upstream.Close()
+	<-done
+}
+
+// reads greeting and connect request of client, returns address to connect to
+func readConnect(conn net.Conn) (string, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	if header[0] != socksVersion {
+		return "", fmt.Errorf("unsupported socks version %d", header[0])
+	}
+
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", err
+	}
+
+	method := byte(socksNoAcceptable)
+	for _, m := range methods {
+		if m == socksMethodNoAuth {
+			method = socksMethodNoAuth
+		}
+	}
+	if _, err := conn.Write([]byte{socksVersion, method}); err != nil {
+		return "", err
+	}
+	if method == socksNoAcceptable {
+		return "", errors.New("client does not support socks5 without authentication")
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return "", err
+	}
+	if request[0] != socksVersion {
+		return "", fmt.Errorf("unsupported socks version %d", request[0])
+	}
+	if request[1] != socksCmdConnect {
+		_ = writeReply(conn, socksReplyCmd)
+		return "", fmt.Errorf("unsupported socks command %d", request[1])
+	}
+
+	var host string
+	switch request[3] {
+	case socksAtypIPv4, socksAtypIPv6:
+		ip := make(net.IP, net.IPv4len)
+		if request[3] == socksAtypIPv6 {
+			ip = make(net.IP, net.IPv6len)
+		}
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", err
+		}
+		host = ip.String()
+	case socksAtypDomain:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return "", err
+		}
+		domain := make([]byte, length[0])
+		if _, err := io.ReadFull(conn, domain); err != nil {
+			return "", err
+		}
+		host = string(domain)
+	default:
+		_ = writeReply(conn, socksReplyAtyp)
+		return "", fmt.Errorf("unsupported socks address type %d", request[3])
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", err
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// writes reply to connect request, bound address is not used by browser so it is left zero
+func writeReply(conn net.Conn, reply byte) error {
+	_, err := conn.Write([]byte{socksVersion, reply, 0x00, socksAtypIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
@@ -0,0 +1,259 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/binary"
+	netproxy "golang.org/x/net/proxy"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	testUser     = "user"
+	testPassword = "password"
+	// address browser asks to connect to, upstream resolves it on its side
+	testTarget = "avito.test:443"
+)
+
+func TestForwarder(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     *url.Userinfo
+		wantDial bool
+	}{
+		{name: "valid credentials", user: url.UserPassword(testUser, testPassword), wantDial: true},
+		{name: "invalid credentials", user: url.UserPassword(testUser, "wrong")},
+		{name: "no credentials", user: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := newTestUpstream(t)
+			f, err := NewForwarder(&url.URL{Scheme: "socks5", Host: upstream.addr, User: tt.user}, "127.0.0.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = f.Close()
+			}()
+
+			if f.Url().User != nil {
+				t.Errorf("expected forwarder url without credentials, got %s", f.Url())
+			}
+
+			conn, err := dialForwarder(t, f)
+			if !tt.wantDial {
+				if err == nil {
+					_ = conn.Close()
+					t.Error("expected dial through forwarder to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer func() {
+				_ = conn.Close()
+			}()
+
+			if got := <-upstream.targets; got != testTarget {
+				t.Errorf("expected upstream to be asked for %s, got %s", testTarget, got)
+			}
+
+			if _, err = conn.Write([]byte("ping\n")); err != nil {
+				t.Fatal(err)
+			}
+			line, err := bufio.NewReader(conn).ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line != "ping\n" {
+				t.Errorf("expected echo of ping, got %q", line)
+			}
+		})
+	}
+}
+
+func TestForwarderClose(t *testing.T) {
+	upstream := newTestUpstream(t)
+	f, err := NewForwarder(&url.URL{Scheme: "socks5", Host: upstream.addr, User: url.UserPassword(testUser, testPassword)}, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := dialForwarder(t, f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// relayed connection is closed along with forwarder
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err = conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected relayed connection to be closed, got %v", err)
+	}
+
+	if _, err = dialForwarder(t, f); err == nil {
+		t.Error("expected closed forwarder to refuse connections")
+	}
+}
+
+func TestNewForwarderScheme(t *testing.T) {
+	if _, err := NewForwarder(&url.URL{Scheme: "http", Host: "10.0.0.1:3128"}, "127.0.0.1"); err == nil {
+		t.Error("expected error of http proxy")
+	}
+}
+
+// dials test target through forwarder as browser does, without credentials
+func dialForwarder(t *testing.T, f *Forwarder) (net.Conn, error) {
+	t.Helper()
+
+	dialer, err := netproxy.SOCKS5("tcp", f.Url().Host, nil, &net.Dialer{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dialer.Dial("tcp", testTarget)
+}
+
+// testUpstream is socks5 proxy with credentials, it connects every client to echo server
+// whatever target is asked for and reports asked target
+type testUpstream struct {
+	addr    string
+	targets chan string
+}
+
+func newTestUpstream(t *testing.T) *testUpstream {
+	t.Helper()
+
+	echo := listen(t, func(conn net.Conn) {
+		_, _ = io.Copy(conn, conn)
+	})
+
+	u := &testUpstream{targets: make(chan string, 1)}
+	u.addr = listen(t, func(conn net.Conn) {
+		target, err := u.handshake(conn)
+		if err != nil {
+			return
+		}
+
+		dst, err := net.Dial("tcp", echo)
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = dst.Close()
+		}()
+
+		u.targets <- target
+		go func() {
+			_, _ = io.Copy(dst, conn)
+		}()
+		_, _ = io.Copy(conn, dst)
+	})
+
+	return u
+}
+
+// serves socks5 handshake with username and password auth (RFC 1929), returns asked target
+func (u *testUpstream) handshake(conn net.Conn) (string, error) {
+	greeting := make([]byte, 2)
+	if _, err := io.ReadFull(conn, greeting); err != nil {
+		return "", err
+	}
+	if _, err := io.ReadFull(conn, make([]byte, greeting[1])); err != nil {
+		return "", err
+	}
+	// clients without credentials don't offer username and password method,
+	// they are told to use it anyway and fail
+	if _, err := conn.Write([]byte{socksVersion, 0x02}); err != nil {
+		return "", err
+	}
+
+	auth := make([]byte, 2)
+	if _, err := io.ReadFull(conn, auth); err != nil {
+		return "", err
+	}
+	user := make([]byte, auth[1])
+	if _, err := io.ReadFull(conn, user); err != nil {
+		return "", err
+	}
+	length := make([]byte, 1)
+	if _, err := io.ReadFull(conn, length); err != nil {
+		return "", err
+	}
+	password := make([]byte, length[0])
+	if _, err := io.ReadFull(conn, password); err != nil {
+		return "", err
+	}
+	if string(user) != testUser || string(password) != testPassword {
+		_, _ = conn.Write([]byte{0x01, 0x01})
+		return "", io.ErrUnexpectedEOF
+	}
+	if _, err := conn.Write([]byte{0x01, 0x00}); err != nil {
+		return "", err
+	}
+
+	request := make([]byte, 5)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return "", err
+	}
+	if request[3] != socksAtypDomain {
+		return "", io.ErrUnexpectedEOF
+	}
+	domain := make([]byte, request[4])
+	if _, err := io.ReadFull(conn, domain); err != nil {
+		return "", err
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", err
+	}
+
+	if err := writeReply(conn, socksReplySucceeded); err != nil {
+		return "", err
+	}
+
+	return net.JoinHostPort(string(domain), strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// serves every connection to returned local address with handle until test ends
+func listen(t *testing.T, handle func(conn net.Conn)) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer func() {
+					_ = conn.Close()
+				}()
+				handle(conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
@@ -0,0 +1,170 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// healthy proxy is not checked again until this passes
+	checkInterval = 5 * time.Minute
+	// unhealthy proxy is not tried again until this passes
+	unhealthyCooldown = 5 * time.Minute
+	// proxy avito blocked is not used again until this passes
+	blockedCooldown = 30 * time.Minute
+	checkTimeout    = 15 * time.Second
+)
+
+// ErrNoProxyAvailable is returned when every proxy of pool is unhealthy or blocked
+var ErrNoProxyAvailable = errors.New("no healthy proxy available")
+
+type Proxy struct {
+	Url *url.URL
+
+	checkedAt time.Time
+	// proxy is skipped until this time
+	unavailableUntil time.Time
+}
+
+// String returns proxy url without credentials, safe to log and store
+func (p *Proxy) String() string {
+	return p.Url.Scheme + "://" + p.Url.Host
+}
+
+// Pool hands out proxies in round-robin order, checking health of each before use
+type Pool struct {
+	mu       sync.Mutex
+	proxies  []*Proxy
+	next     int
+	checkUrl string
+}
+
+// ParseUrl parses proxy url, http, https and socks5 proxies are supported, any of them may have credentials
+func ParseUrl(s string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || u.Host == "" {
+		return nil, errors.New("malformed proxy url")
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("proxy %s://%s: unsupported scheme, expected http, https or socks5", u.Scheme, u.Host)
+	}
+
+	return u, nil
+}
+
+// NewPool returns pool of given proxies, checkUrl is requested through every proxy to check its health
+func NewPool(urls []string, checkUrl string) (*Pool, error) {
+	pool := &Pool{checkUrl: checkUrl}
+	for _, s := range urls {
+		u, err := ParseUrl(s)
+		if err != nil {
+			return nil, err
+		}
+
+		pool.proxies = append(pool.proxies, &Proxy{Url: u})
+	}
+
+	if len(pool.proxies) == 0 {
+		return nil, errors.New("proxy pool must not be empty")
+	}
+
+	return pool, nil
+}
+
+// Acquire returns next available proxy, proxy that was not checked recently
+// is checked first and skipped when unhealthy
+func (p *Pool) Acquire(ctx context.Context) (*Proxy, error) {
+	for range p.proxies {
+		proxy := p.pick()
+		if proxy == nil {
+			break
+		}
+
+		if !p.needsCheck(proxy) {
+			return proxy, nil
+		}
+
+		err := check(ctx, proxy, p.checkUrl)
+		p.mu.Lock()
+		if err != nil {
+			proxy.unavailableUntil = time.Now().Add(unhealthyCooldown)
+		} else {
+			proxy.checkedAt = time.Now()
+		}
+		p.mu.Unlock()
+
+		if err == nil {
+			return proxy, nil
+		}
+	}
+
+	return nil, ErrNoProxyAvailable
+}
+
+// MarkBlocked takes proxy out of rotation for a while
+func (p *Pool) MarkBlocked(proxy *Proxy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	proxy.unavailableUntil = time.Now().Add(blockedCooldown)
+}
+
+// returns next proxy in order that is not unavailable, nil when there is none
+func (p *Pool) pick() *Proxy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for range p.proxies {
+		proxy := p.proxies[p.next]
+		p.next = (p.next + 1) % len(p.proxies)
+
+		if now.After(proxy.unavailableUntil) {
+			return proxy
+		}
+	}
+
+	return nil
+}
+
+func (p *Pool) needsCheck(proxy *Proxy) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return time.Since(proxy.checkedAt) > checkInterval
+}
+
+// requests url through proxy, any response below 500 means proxy works
+func check(ctx context.Context, proxy *Proxy, checkUrl string) error {
+	client := &http.Client{
+		Timeout:   checkTimeout,
+		Transport: &http.Transport{Proxy: http.ProxyURL(proxy.Url)},
+	}
+	defer client.CloseIdleConnections()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, checkUrl, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("health check of proxy %s got status %d", proxy, res.StatusCode)
+	}
+
+	return nil
+}
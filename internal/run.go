@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
+	"github.com/uptrace/bun"
+	"os"
+	"time"
+)
+
+// StartRun records start of parsing run identified by its TraceId
+func StartRun(ctx context.Context, connection bun.IDB, traceId string, dryRun bool, taskCount int) (*db.EstateParsingRunModel, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	run := &db.EstateParsingRunModel{
+		Id:            traceId,
+		StartedAt:     time.Now(),
+		DryRun:        dryRun,
+		TaskCount:     taskCount,
+		ParserVersion: Version,
+		Host:          host,
+	}
+
+	if err = db.InsertRun(ctx, connection, run); err != nil {
+		return nil, fmt.Errorf("error saving run: %v", err)
+	}
+
+	return run, nil
+}
+
+// FinishRun records end of parsing run with counts of succeeded, failed and skipped tasks
+func FinishRun(ctx context.Context, connection bun.IDB, run *db.EstateParsingRunModel, results []*ParsingTaskResult, failures []*ParsingTaskFailure) error {
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.SuccessCount, run.FailureCount, run.SkippedCount = countRunTasks(run.TaskCount, results, failures)
+
+	if err := db.UpdateRun(ctx, connection, run); err != nil {
+		return fmt.Errorf("error saving run: %v", err)
+	}
+
+	return nil
+}
+
+// counts tasks of run by outcome: task failed when none of its attempts succeeded,
+// tasks without any attempt were skipped, eg. because run was aborted or cancelled
+func countRunTasks(taskCount int, results []*ParsingTaskResult, failures []*ParsingTaskFailure) (successCount int, failureCount int, skippedCount int) {
+	isSucceeded := make(map[*ParsingTask]bool, len(results))
+	for _, result := range results {
+		isSucceeded[result.Task] = true
+	}
+
+	isFailed := make(map[*ParsingTask]bool)
+	for _, failure := range failures {
+		if !isSucceeded[failure.Task] {
+			isFailed[failure.Task] = true
+		}
+	}
+
+	successCount, failureCount = len(isSucceeded), len(isFailed)
+	return successCount, failureCount, taskCount - successCount - failureCount
+}
@@ -0,0 +1,63 @@
+package internal
+
+import "testing"
+
+func TestCountRunTasks(t *testing.T) {
+	tasks := []*ParsingTask{{Id: 1}, {Id: 2}, {Id: 3}, {Id: 4}}
+	result := func(i int) *ParsingTaskResult {
+		return &ParsingTaskResult{Task: tasks[i]}
+	}
+	failure := func(i int, attempt int) *ParsingTaskFailure {
+		return &ParsingTaskFailure{Task: tasks[i], Attempt: attempt}
+	}
+
+	tests := []struct {
+		name        string
+		results     []*ParsingTaskResult
+		failures    []*ParsingTaskFailure
+		wantSuccess int
+		wantFailure int
+		wantSkipped int
+	}{
+		{
+			name:        "every task succeeded",
+			results:     []*ParsingTaskResult{result(0), result(1), result(2), result(3)},
+			wantSuccess: 4,
+		},
+		{
+			name:        "task succeeded on retry",
+			results:     []*ParsingTaskResult{result(0), result(1), result(2), result(3)},
+			failures:    []*ParsingTaskFailure{failure(1, 1), failure(1, 2)},
+			wantSuccess: 4,
+		},
+		{
+			name:        "task failed on every attempt",
+			results:     []*ParsingTaskResult{result(0), result(2), result(3)},
+			failures:    []*ParsingTaskFailure{failure(1, 1), failure(1, 2), failure(1, 3)},
+			wantSuccess: 3,
+			wantFailure: 1,
+		},
+		{
+			name:        "tasks left after abort",
+			results:     []*ParsingTaskResult{result(0)},
+			failures:    []*ParsingTaskFailure{failure(1, 1)},
+			wantSuccess: 1,
+			wantFailure: 1,
+			wantSkipped: 2,
+		},
+		{
+			name:        "nothing attempted",
+			wantSkipped: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			success, failure, skipped := countRunTasks(len(tasks), tt.results, tt.failures)
+			if success != tt.wantSuccess || failure != tt.wantFailure || skipped != tt.wantSkipped {
+				t.Errorf("expected %d succeeded, %d failed, %d skipped, got %d, %d, %d",
+					tt.wantSuccess, tt.wantFailure, tt.wantSkipped, success, failure, skipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package selector
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+)
+
+// DefaultVersion is reported when built-in selectors are used
+const DefaultVersion = "builtin"
+
+// Config replaces built-in selectors by name, names not mentioned keep built-in values
+type Config struct {
+	Version string `yaml:"version" json:"version"`
+	// Selectors are keyed by variable name, eg. PageTitleCount
+	Selectors map[string]string `yaml:"selectors" json:"selectors"`
+	// Templates are keyed by function name, eg. CalendarBtn, and take day of month in place of %d
+	Templates map[string]string `yaml:"templates" json:"templates"`
+}
+
+var selectors = map[string]*Selector{
+	"ModalDialog":                                &ModalDialog,
+	"ModalDialogCloseBtn":                        &ModalDialogCloseBtn,
+	"PageTitleCount":                             &PageTitleCount,
+	"PageTitleText":                              &PageTitleText,
+	"EmptyResults":                               &EmptyResults,
+	"SubmitFiltersBtn":                           &SubmitFiltersBtn,
+	"LocationChangeButton":                       &LocationChangeButton,
+	"LocationPopupInput":                         &LocationPopupInput,
+	"LocationPopupSuggestion":                    &LocationPopupSuggestion,
+	"LocationPopupSaveButton":                    &LocationPopupSaveButton,
+	"BaseEstateWidgetTypeFilterButton":           &BaseEstateWidgetTypeFilterButton,
+	"BaseEstateWidgetTypeFilterDropdown":         &BaseEstateWidgetTypeFilterDropdown,
+	"BaseEstateWidgetActionFilterButton":         &BaseEstateWidgetActionFilterButton,
+	"BaseEstateWidgetDurationDailyRentButton":    &BaseEstateWidgetDurationDailyRentButton,
+	"WidgetSubmitButton":                         &WidgetSubmitButton,
+	"DailyRentWidgetPageCalendarButton":          &DailyRentWidgetPageCalendarButton,
+	"DailyRentWidgetPageCalendarNextMonthButton": &DailyRentWidgetPageCalendarNextMonthButton,
+	"DailyRentWidgetPageCalendarTitle":           &DailyRentWidgetPageCalendarTitle,
+	"FilterCalendarResetButton":                  &FilterCalendarResetButton,
+	"ListingCard":                                &ListingCard,
+	"ListingCardTitle":                           &ListingCardTitle,
+	"ListingCardPrice":                           &ListingCardPrice,
+	"ListingCardPriceText":                       &ListingCardPriceText,
+	"ListingCardAddress":                         &ListingCardAddress,
+	"ListingCardSellerType":                      &ListingCardSellerType,
+	"PaginationNextPageButton":                   &PaginationNextPageButton,
+	"FirewallContainer":                          &FirewallContainer,
+	"FirewallTitle":                              &FirewallTitle,
+	"Captcha":                                    &Captcha,
+}
+
+var templates = map[string]*string{
+	"CalendarBtn":                          &calendarButtonSelectorTemplate,
+	"DailyRentWidgetPageCalendarDayButton": &dailyRentWidgetCalendarDayButtonTemplate,
+}
+
+// built-in values captured before any config is applied
+var defaultSelectors, defaultTemplates = snapshot()
+
+var version = DefaultVersion
+
+func snapshot() (map[string]Selector, map[string]string) {
+	s := make(map[string]Selector, len(selectors))
+	for name, sel := range selectors {
+		s[name] = *sel
+	}
+
+	t := make(map[string]string, len(templates))
+	for name, template := range templates {
+		t[name] = *template
+	}
+
+	return s, t
+}
+
+// Version returns version of applied config
+func Version() string {
+	return version
+}
+
+// IsTemplate tells whether name is of selector template rather than selector
+func IsTemplate(name string) bool {
+	_, ok := templates[name]
+	return ok
+}
+
+// LoadFile reads config from yaml or json file, json being valid yaml
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read selector config: %v", err)
+	}
+
+	config := &Config{}
+	if err = yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse selector config %s: %v", path, err)
+	}
+
+	return config, nil
+}
+
+// Apply resets selectors to built-in values and replaces the ones given in config,
+// config is validated as a whole first, so invalid one leaves selectors intact;
+// must not be called while parser is running
+func Apply(config *Config) error {
+	if strings.TrimSpace(config.Version) == "" {
+		return errors.New("selector config must have a version")
+	}
+
+	for name, value := range config.Selectors {
+		if _, ok := selectors[name]; !ok {
+			return fmt.Errorf("unknown selector %q", name)
+		}
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("selector %q must not be empty", name)
+		}
+	}
+
+	for name, value := range config.Templates {
+		if _, ok := templates[name]; !ok {
+			return fmt.Errorf("unknown selector template %q", name)
+		}
+		if strings.Count(value, "%") != 1 || !strings.Contains(value, "%d") {
+			return fmt.Errorf("selector template %q must contain single %%d in place of day", name)
+		}
+	}
+
+	Reset()
+
+	for name, value := range config.Selectors {
+		*selectors[name] = Selector(value)
+	}
+	for name, value := range config.Templates {
+		*templates[name] = value
+	}
+	version = config.Version
+
+	return nil
+}
+
+// Reset restores built-in selectors
+func Reset() {
+	for name, sel := range defaultSelectors {
+		*selectors[name] = sel
+	}
+	for name, template := range defaultTemplates {
+		*templates[name] = template
+	}
+	version = DefaultVersion
+}
@@ -11,13 +11,18 @@ func (s Selector) String() string {
 	return string(s)
 }
 
-const (
+// selectors are variables so they can be replaced by loaded configuration, see Apply
+var (
 	ModalDialog                                Selector = "div[aria-modal=\"true\"][role=\"dialog\"][tabindex=\"-1\"]"
 	ModalDialogCloseBtn                        Selector = "button[type=\"button\"][aria-label=\"закрыть\"]"
 	PageTitleCount                             Selector = "span[data-marker=\"page-title/count\"]"
 	PageTitleText                              Selector = "h1"
+	EmptyResults                               Selector = "div[data-marker=\"empty-result\"]"
 	SubmitFiltersBtn                           Selector = "button[data-marker=\"search-filters/submit-button\"]"
 	LocationChangeButton                       Selector = "div[data-marker=\"search-form/change-location\"]"
+	LocationPopupInput                         Selector = "input[data-marker=\"popup-location/region-search-input\"]"
+	LocationPopupSuggestion                    Selector = "button[data-marker^=\"popup-location/region-search-item\"]"
+	LocationPopupSaveButton                    Selector = "button[data-marker=\"popup-location/save-button\"]"
 	BaseEstateWidgetTypeFilterButton           Selector = "input[data-marker=\"categoryId\"]"
 	BaseEstateWidgetTypeFilterDropdown         Selector = "div[class^=\"dropdown-list-dropdown-list\"]"
 	BaseEstateWidgetActionFilterButton         Selector = "input[data-marker=\"param[201]\"]"
@@ -27,14 +32,28 @@ const (
 	DailyRentWidgetPageCalendarNextMonthButton Selector = "button[data-marker=\"params[2903]/next-button\"]"
 	DailyRentWidgetPageCalendarTitle           Selector = "div[class^=\"datepicker-title\"]"
 	FilterCalendarResetButton                  Selector = "a[data-marker=\"params[2903]-reset\"]"
+	ListingCard                                Selector = "div[data-marker=\"item\"][data-item-id]"
+	ListingCardTitle                           Selector = "a[data-marker=\"item-title\"]"
+	ListingCardPrice                           Selector = "[data-marker=\"item-price\"] meta[itemprop=\"price\"]"
+	ListingCardPriceText                       Selector = "[data-marker=\"item-price\"]"
+	ListingCardAddress                         Selector = "div[data-marker=\"item-address\"]"
+	ListingCardSellerType                      Selector = "div[class*=\"iva-item-sellerInfo\"] p"
+	PaginationNextPageButton                   Selector = "a[data-marker=\"pagination-button/nextPage\"]"
+	FirewallContainer                          Selector = "div[class*=\"firewall-container\"]"
+	FirewallTitle                              Selector = "h2[class*=\"firewall-title\"]"
+	Captcha                                    Selector = "form[class*=\"captcha\"], iframe[src*=\"captcha\"], div[class*=\"geetest\"]"
+)
+
+// templates take day of month in place of %d
+var (
+	calendarButtonSelectorTemplate           = "td[data-marker^=\"params[\"][data-marker$=\"/day(%d)\"] div[role=button][class*=\"styles-module-day_hoverable-\"]"
+	dailyRentWidgetCalendarDayButtonTemplate = "td[data-marker=\"day(%d)\"] div"
 )
 
 func CalendarBtn(t *time.Time) Selector {
-	const calendarButtonSelectorTemplate = "td[data-marker^=\"params[\"][data-marker$=\"/day(%d)\"] div[role=button][class*=\"styles-module-day_hoverable-\"]"
 	return Selector(fmt.Sprintf(calendarButtonSelectorTemplate, t.Day()))
 }
 
 func DailyRentWidgetPageCalendarDayButton(t *time.Time) Selector {
-	const template = "td[data-marker=\"day(%d)\"] div"
-	return Selector(fmt.Sprintf(template, t.Day()))
+	return Selector(fmt.Sprintf(dailyRentWidgetCalendarDayButtonTemplate, t.Day()))
 }
@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"github.com/csr-ugra/avito-estate-parser/internal/db"
+	"github.com/csr-ugra/avito-estate-parser/internal/selector"
+	"github.com/uptrace/bun"
+)
+
+// SelectorSourceDb makes LoadSelectors read overrides from avito_estate_selectors table
+const SelectorSourceDb = "db"
+
+// LoadSelectors applies selector config from source: built-in selectors are used when source is empty,
+// SelectorSourceDb reads them from db, any other value is a path to yaml or json file
+func LoadSelectors(ctx context.Context, connection bun.IDB, source string) error {
+	switch source {
+	case "":
+		selector.Reset()
+		return nil
+	case SelectorSourceDb:
+		config, err := loadSelectorsFromDb(ctx, connection)
+		if err != nil {
+			return err
+		}
+
+		return selector.Apply(config)
+	}
+
+	config, err := selector.LoadFile(source)
+	if err != nil {
+		return err
+	}
+
+	if err = selector.Apply(config); err != nil {
+		return fmt.Errorf("invalid selector config %s: %v", source, err)
+	}
+
+	return nil
+}
+
+// version of db config is the one of the most recently updated row
+func loadSelectorsFromDb(ctx context.Context, connection bun.IDB) (*selector.Config, error) {
+	models, err := db.GetSelectors(ctx, connection)
+	if err != nil {
+		return nil, fmt.Errorf("error getting selectors from db: %v", err)
+	}
+
+	config := &selector.Config{
+		Version:   selector.DefaultVersion,
+		Selectors: make(map[string]string),
+		Templates: make(map[string]string),
+	}
+	if len(models) > 0 {
+		config.Version = models[0].Version
+	}
+
+	for _, m := range models {
+		if selector.IsTemplate(m.Name) {
+			config.Templates[m.Name] = m.Value
+			continue
+		}
+
+		config.Selectors[m.Name] = m.Value
+	}
+
+	return config, nil
+}
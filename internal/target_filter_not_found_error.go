@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TargetFilterNotFoundError is returned when base estate widget has no estate type
+// with filter text of task target
+type TargetFilterNotFoundError struct {
+	FilterText string
+}
+
+func NewTargetFilterNotFoundError(filterText string) *TargetFilterNotFoundError {
+	return &TargetFilterNotFoundError{FilterText: filterText}
+}
+
+func (e TargetFilterNotFoundError) Error() string {
+	return fmt.Sprintf("target filter %q not found", e.FilterText)
+}
+
+func (e TargetFilterNotFoundError) Is(target error) bool {
+	var t *TargetFilterNotFoundError
+	ok := errors.As(target, &t)
+	return ok
+}
+
+func (e TargetFilterNotFoundError) Kind() ErrorKind {
+	return ErrorKindTargetFilterNotFound
+}
@@ -3,7 +3,6 @@ package internal
 import (
 	"context"
 	"errors"
-	"flag"
 	"fmt"
 	"github.com/csr-ugra/avito-estate-parser/internal/db"
 	"github.com/uptrace/bun"
@@ -31,12 +30,55 @@ type ParsingTask struct {
 	Url           string
 	DateStart     *time.Time
 	DateEnd       *time.Time
+	// CrawlPages enables following pagination to collect listings from every result page
+	CrawlPages bool
+	// CrawlPageLimit overrides global page cap when greater than zero
+	CrawlPageLimit int
+	// RetryMaxAttempts and RetryBaseDelay override global retry policy when greater than zero
+	RetryMaxAttempts int
+	RetryBaseDelay   time.Duration
+	// ConfigErr is set when task can't be run as configured, eg. its location has no url part;
+	// such task fails without opening a page, so other tasks of the run are not affected
+	ConfigErr error
 }
 
 type ParsingTaskResult struct {
 	Task             *ParsingTask
 	EstateTotalCount int
 	EstateFreeCount  int
+	Listings         []*Listing
+	Crawl            *CrawlStats
+	Prices           *PriceStats
+	// IsNoResults is set when page explicitly reported nothing was found
+	IsNoResults bool
+	// IsDateFilterApplied is set when both calendar dates were picked before free count was taken
+	IsDateFilterApplied bool
+	// SuspectReason is set when result did not pass validation but was stored anyway
+	SuspectReason string
+	// Proxy served the result, empty when no proxy is configured
+	Proxy string
+}
+
+// CrawlStats describes paginated crawl of task result pages,
+// nil on result when crawl is not enabled for task
+type CrawlStats struct {
+	PageCount    int
+	ListingCount int
+	// IsComplete is false when crawl stopped at the page cap before the last page
+	IsComplete bool
+	// IsCountMismatch is set when complete crawl collected different number
+	// of listings than the page header reported
+	IsCountMismatch bool
+}
+
+// Listing is a single offer card from the filtered estate list page
+type Listing struct {
+	AvitoId    int64
+	Title      string
+	Price      int
+	Address    string
+	Url        string
+	SellerType string
 }
 
 func getLocationById(locations []*db.EstateLocationModel, id int) (location *db.EstateLocationModel, exist bool) {
@@ -81,10 +123,8 @@ func NewParsingTask(task *db.EstateParsingTaskModel, locations []*db.EstateLocat
 		return nil, fmt.Errorf("target with id %d not found", task.EstateTargetId)
 	}
 
-	url, err := buildUrl(location, target)
-	if err != nil {
-		return nil, err
-	}
+	// url error is kept on task, so it fails on its own instead of the whole run
+	url, configErr := buildUrl(location, target)
 
 	return &ParsingTask{
 		Id: task.Id,
@@ -93,14 +133,21 @@ func NewParsingTask(task *db.EstateParsingTaskModel, locations []*db.EstateLocat
 			Name: location.Name,
 		},
 		Target: &parsingTaskTarget{
-			Id:   target.Id,
-			Name: target.Name,
+			Id:            target.Id,
+			Name:          target.Name,
+			FilterText:    target.FilterText,
+			SubfilterText: target.SubfilterText,
 		},
-		Description:   task.Description,
-		ValidateTitle: task.ValidateTitle,
-		Url:           url,
-		DateStart:     &dateStart,
-		DateEnd:       &dateEnd,
+		Description:      task.Description,
+		ValidateTitle:    task.ValidateTitle,
+		Url:              url,
+		DateStart:        &dateStart,
+		DateEnd:          &dateEnd,
+		CrawlPages:       task.CrawlPages,
+		CrawlPageLimit:   task.CrawlPageLimit,
+		RetryMaxAttempts: task.RetryMaxAttempts,
+		RetryBaseDelay:   time.Duration(task.RetryBaseDelaySeconds) * time.Second,
+		ConfigErr:        configErr,
 	}, nil
 }
 
@@ -108,11 +155,11 @@ func buildUrl(location *db.EstateLocationModel, target *db.EstateTargetModel) (u
 	const urlFormat = "https://www.avito.ru/%s/%s"
 
 	if location.UrlPart == "" {
-		return "", fmt.Errorf("location model does not have a url part")
+		return "", NewConfigError("location model does not have a url part")
 	}
 
 	if target.UrlPart == "" {
-		return "", fmt.Errorf("target model does not have a url part")
+		return "", NewConfigError("target model does not have a url part")
 	}
 
 	url = fmt.Sprintf(urlFormat, location.UrlPart, target.UrlPart)
@@ -120,7 +167,23 @@ func buildUrl(location *db.EstateLocationModel, target *db.EstateTargetModel) (u
 	return url, nil
 }
 
-func LoadTasks(ctx context.Context, connection bun.IDB) (tasks []*ParsingTask, err error) {
+// builds url of base estate page of location, the one with "Недвижимость в" widget
+func buildBaseEstateUrl(location *db.EstateLocationModel) (url string, err error) {
+	const urlFormat = "https://www.avito.ru/%s/nedvizhimost"
+
+	if location.UrlPart == "" {
+		return "", NewConfigError("location model does not have a url part")
+	}
+
+	return fmt.Sprintf(urlFormat, location.UrlPart), nil
+}
+
+// LoadTasks expands every task from db into one parsing task per date window
+func LoadTasks(ctx context.Context, connection bun.IDB, windows []DateWindow) (tasks []*ParsingTask, err error) {
+	if len(windows) == 0 {
+		return nil, errors.New("no date windows specified")
+	}
+
 	locations, err := db.GetLocations(ctx, connection)
 	if err != nil {
 		return nil, err
@@ -137,7 +200,7 @@ func LoadTasks(ctx context.Context, connection bun.IDB) (tasks []*ParsingTask, e
 		return nil, errors.New("no targets specified")
 	}
 
-	taskList, err := db.GetTasks(ctx, connection)
+	taskList, err := db.GetEnabledTasks(ctx, connection)
 	if err != nil {
 		return nil, err
 	}
@@ -145,66 +208,156 @@ func LoadTasks(ctx context.Context, connection bun.IDB) (tasks []*ParsingTask, e
 		return nil, errors.New("no tasks specified")
 	}
 
-	tasks = make([]*ParsingTask, 0, len(taskList))
+	tasks = make([]*ParsingTask, 0, len(taskList)*len(windows))
 	for _, task := range taskList {
-		//dateEnd := time.Now().Add(48 * time.Hour)
-
-		var dateStart time.Time
-		dateStartFlag := flag.Lookup("date-start")
-		if dateStartFlag != nil {
-			if dateStartFlag.Value.String() != "" {
-				dateStart, err = time.Parse(time.DateOnly, dateStartFlag.Value.String())
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				dateStart, err = time.Parse(time.DateOnly, dateStartFlag.DefValue)
-				if err != nil {
-					return nil, err
-				}
+		for _, window := range windows {
+			t, err := NewParsingTask(task, locations, targets, window.Start, window.End)
+			if err != nil {
+				return nil, fmt.Errorf("error creating parsing task: %v", err)
 			}
-		}
 
-		var dateEnd time.Time
-		dateEndFlag := flag.Lookup("date-end")
-		if dateEndFlag != nil {
-			if dateEndFlag.Value.String() != "" {
-				dateEnd, err = time.Parse(time.DateOnly, dateEndFlag.Value.String())
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				dateEnd = dateStart.Add(24 * time.Hour)
-			}
+			tasks = append(tasks, t)
 		}
+	}
+
+	return tasks, nil
+}
+
+// SaveTaskResults stores results of run as observations of their windows, the first observation
+// of window is also stored as its value; returns results that were actually inserted,
+// result already stored for the run is skipped
+func SaveTaskResults(ctx context.Context, connection bun.IDB, runId string, results []*ParsingTaskResult) ([]*ParsingTaskResult, error) {
+	values := make([]*db.EstateParsingValueModel, 0, len(results))
+	for _, result := range results {
+		values = append(values, &db.EstateParsingValueModel{
+			TaskId:                  result.Task.Id,
+			DateStart:               result.Task.DateStart,
+			DateEnd:                 result.Task.DateEnd,
+			EstateParsingStatsModel: newStatsModel(result),
+			RunId:                   runId,
+		})
+	}
+
+	if err := db.SaveValues(ctx, connection, values); err != nil {
+		return nil, fmt.Errorf("error savings task results: %v", err)
+	}
+
+	observations := make([]*db.EstateParsingObservationModel, 0, len(results))
+	for i, result := range results {
+		observations = append(observations, &db.EstateParsingObservationModel{
+			ValueId:                 values[i].Id,
+			EstateParsingStatsModel: newStatsModel(result),
+			RunId:                   runId,
+		})
+	}
+
+	insertedObservations, err := db.SaveObservations(ctx, connection, observations)
+	if err != nil {
+		return nil, fmt.Errorf("error savings task results: %v", err)
+	}
 
-		t, err := NewParsingTask(task, locations, targets, dateStart, dateEnd)
-		if err != nil {
-			return nil, fmt.Errorf("error creating parsing task: %v", err)
+	isInserted := make(map[int]bool, len(insertedObservations))
+	for _, observation := range insertedObservations {
+		isInserted[observation.ValueId] = true
+	}
+
+	inserted := make([]*ParsingTaskResult, 0, len(insertedObservations))
+	for i, result := range results {
+		if isInserted[values[i].Id] {
+			inserted = append(inserted, result)
 		}
+	}
 
-		tasks = append(tasks, t)
+	return inserted, nil
+}
+
+// builds counts and stats columns of result, crawl stats and prices are left null when they were not collected
+func newStatsModel(result *ParsingTaskResult) db.EstateParsingStatsModel {
+	stats := db.EstateParsingStatsModel{
+		EstateTotalCount: result.EstateTotalCount,
+		EstateFreeCount:  result.EstateFreeCount,
+		SuspectReason:    result.SuspectReason,
+		Proxy:            result.Proxy,
 	}
 
-	return tasks, nil
+	if crawl := result.Crawl; crawl != nil {
+		stats.CrawlPageCount = &crawl.PageCount
+		stats.CrawlListingCount = &crawl.ListingCount
+		stats.CrawlCountMismatch = &crawl.IsCountMismatch
+	}
+
+	if prices := result.Prices; prices != nil {
+		stats.PriceMin = &prices.Min
+		stats.PriceMax = &prices.Max
+		stats.PriceMean = &prices.Mean
+		stats.PriceMedian = &prices.Median
+		stats.PriceP25 = &prices.P25
+		stats.PriceP75 = &prices.P75
+		stats.PriceSampleCount = prices.SampleCount
+	}
+
+	return stats
 }
 
-func SaveTaskResults(ctx context.Context, connection bun.IDB, results []*ParsingTaskResult) (int, error) {
-	models := make([]*db.EstateParsingValueModel, 0, len(results))
+// SaveTaskListings stores listings of results and links them to observations run stored for their windows,
+// so offer seen by several runs is stored once, but with every run it was seen in
+func SaveTaskListings(ctx context.Context, connection bun.IDB, runId string, results []*ParsingTaskResult) (int, error) {
+	windows, err := db.GetRunObservationWindows(ctx, connection, runId)
+	if err != nil {
+		return 0, fmt.Errorf("error savings task listings: %v", err)
+	}
+
+	observationIds := make(map[string]int, len(windows))
+	for _, window := range windows {
+		observationIds[windowKey(window.TaskId, window.DateStart, window.DateEnd)] = window.ObservationId
+	}
+
+	listings := make([]*db.EstateListingModel, 0)
+	observations := make([]*db.EstateListingObservationModel, 0)
 	for _, result := range results {
-		models = append(models, &db.EstateParsingValueModel{
-			TaskId:           result.Task.Id,
-			DateStart:        result.Task.DateStart,
-			DateEnd:          result.Task.DateEnd,
-			EstateTotalCount: result.EstateTotalCount,
-			EstateFreeCount:  result.EstateFreeCount,
-		})
+		observationId, ok := observationIds[windowKey(result.Task.Id, result.Task.DateStart, result.Task.DateEnd)]
+		if !ok {
+			// result was not stored, eg. it was rejected, so there is nothing to link its listings to
+			continue
+		}
+
+		for _, listing := range result.Listings {
+			listings = append(listings, &db.EstateListingModel{
+				TaskId:     result.Task.Id,
+				DateStart:  result.Task.DateStart,
+				DateEnd:    result.Task.DateEnd,
+				AvitoId:    listing.AvitoId,
+				Title:      listing.Title,
+				Price:      listing.Price,
+				Address:    listing.Address,
+				Url:        listing.Url,
+				SellerType: listing.SellerType,
+			})
+			observations = append(observations, &db.EstateListingObservationModel{
+				ObservationId: observationId,
+				Price:         listing.Price,
+			})
+		}
+	}
+
+	if err = db.SaveListings(ctx, connection, listings); err != nil {
+		return 0, fmt.Errorf("error savings task listings: %v", err)
 	}
 
-	insertedCount, err := db.SaveValues(ctx, connection, models)
+	for i, listing := range listings {
+		observations[i].ListingId = listing.Id
+	}
+
+	insertedCount, err := db.SaveListingObservations(ctx, connection, observations)
 	if err != nil {
-		return 0, fmt.Errorf("error savings task results: %v", err)
+		return 0, fmt.Errorf("error savings task listings: %v", err)
 	}
 
 	return insertedCount, nil
 }
+
+// identifies date window of task, dates are compared as days in utc
+// since that is how they are written to date columns and read back
+func windowKey(taskId int, dateStart *time.Time, dateEnd *time.Time) string {
+	return fmt.Sprintf("%d/%s/%s", taskId, dateStart.UTC().Format(time.DateOnly), dateEnd.UTC().Format(time.DateOnly))
+}
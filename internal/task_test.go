@@ -0,0 +1,61 @@
+package internal
+
+import "testing"
+
+func TestNewStatsModelCrawlStats(t *testing.T) {
+	tests := []struct {
+		name  string
+		crawl *CrawlStats
+		// crawl columns stay null when crawl is not enabled
+		wantRecorded bool
+		wantMismatch bool
+	}{
+		{
+			name: "crawl not enabled",
+		},
+		{
+			name:         "complete crawl",
+			crawl:        &CrawlStats{PageCount: 2, ListingCount: 3, IsComplete: true},
+			wantRecorded: true,
+		},
+		{
+			name:         "count mismatch",
+			crawl:        &CrawlStats{PageCount: 2, ListingCount: 3, IsComplete: true, IsCountMismatch: true},
+			wantRecorded: true,
+			wantMismatch: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := date(t, "2026-11-10")
+			end := date(t, "2026-11-12")
+			result := &ParsingTaskResult{
+				Task:             &ParsingTask{Id: 1, DateStart: &start, DateEnd: &end},
+				EstateTotalCount: 1204,
+				EstateFreeCount:  3,
+				Crawl:            tt.crawl,
+			}
+
+			model := newStatsModel(result)
+
+			if !tt.wantRecorded {
+				if model.CrawlPageCount != nil || model.CrawlListingCount != nil || model.CrawlCountMismatch != nil {
+					t.Errorf("expected crawl columns to stay null, got %v, %v, %v",
+						model.CrawlPageCount, model.CrawlListingCount, model.CrawlCountMismatch)
+				}
+				return
+			}
+
+			if model.CrawlPageCount == nil || *model.CrawlPageCount != tt.crawl.PageCount {
+				t.Errorf("expected page count %d, got %v", tt.crawl.PageCount, model.CrawlPageCount)
+			}
+			if model.CrawlListingCount == nil || *model.CrawlListingCount != tt.crawl.ListingCount {
+				t.Errorf("expected listing count %d, got %v", tt.crawl.ListingCount, model.CrawlListingCount)
+			}
+			if model.CrawlCountMismatch == nil || *model.CrawlCountMismatch != tt.wantMismatch {
+				t.Errorf("expected count mismatch %v, got %v", tt.wantMismatch, model.CrawlCountMismatch)
+			}
+		})
+	}
+}
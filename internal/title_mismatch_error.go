@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+type TitleMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func NewTitleMismatchError(expected string, actual string) *TitleMismatchError {
+	return &TitleMismatchError{Expected: expected, Actual: actual}
+}
+
+func (e TitleMismatchError) Error() string {
+	return fmt.Sprintf("page title %q does not match expected %q", e.Actual, e.Expected)
+}
+
+func (e TitleMismatchError) Is(target error) bool {
+	var t *TitleMismatchError
+	ok := errors.As(target, &t)
+	return ok
+}
+
+func (e TitleMismatchError) Kind() ErrorKind {
+	return ErrorKindTitleMismatch
+}
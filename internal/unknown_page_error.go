@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+type UnknownPageError struct {
+	Title string
+}
+
+func NewUnknownPageError(title string) *UnknownPageError {
+	return &UnknownPageError{Title: title}
+}
+
+func (e UnknownPageError) Error() string {
+	return fmt.Sprintf("current page is unknown, can't navigate; page title is %q", e.Title)
+}
+
+func (e UnknownPageError) Is(target error) bool {
+	var t *UnknownPageError
+	ok := errors.As(target, &t)
+	return ok
+}
+
+func (e UnknownPageError) Kind() ErrorKind {
+	return ErrorKindUnknownPage
+}
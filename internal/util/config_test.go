@@ -0,0 +1,96 @@
+package util
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestExtractConfigFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantFlags map[string]string
+		wantRest  []string
+		wantErr   bool
+	}{
+		{
+			name:      "no args",
+			args:      nil,
+			wantFlags: map[string]string{},
+		},
+		{
+			name:      "flag with separate value",
+			args:      []string{"-seq-url", "http://seq:5341", "serve"},
+			wantFlags: map[string]string{"seq-url": "http://seq:5341"},
+			wantRest:  []string{"serve"},
+		},
+		{
+			name:      "flag with value after equals sign and double dash",
+			args:      []string{"--seq-url=http://seq:5341", "-config", "config.yaml"},
+			wantFlags: map[string]string{"seq-url": "http://seq:5341", "config": "config.yaml"},
+		},
+		{
+			name:      "bool flag without value",
+			args:      []string{"-stealth", "serve"},
+			wantFlags: map[string]string{"stealth": "true"},
+			wantRest:  []string{"serve"},
+		},
+		{
+			name:      "bool flag does not take next argument",
+			args:      []string{"-stealth", "false"},
+			wantFlags: map[string]string{"stealth": "true"},
+			wantRest:  []string{"false"},
+		},
+		{
+			name:      "bool flag with value after equals sign",
+			args:      []string{"-stealth=false", "-proxy-mode", "task"},
+			wantFlags: map[string]string{"stealth": "false", "proxy-mode": "task"},
+		},
+		{
+			name:      "config flags after subcommand are left to it",
+			args:      []string{"-stealth", "tasks", "add", "-description", "x", "-seq-url", "http://seq:5341"},
+			wantFlags: map[string]string{"stealth": "true"},
+			wantRest:  []string{"tasks", "add", "-description", "x", "-seq-url", "http://seq:5341"},
+		},
+		{
+			name:      "scanning stops at other flag",
+			args:      []string{"-concurrency", "2", "-stealth"},
+			wantFlags: map[string]string{},
+			wantRest:  []string{"-concurrency", "2", "-stealth"},
+		},
+		{
+			name:      "scanning stops at terminator",
+			args:      []string{"-stealth", "--", "-seq-url", "http://seq:5341"},
+			wantFlags: map[string]string{"stealth": "true"},
+			wantRest:  []string{"--", "-seq-url", "http://seq:5341"},
+		},
+		{
+			name:    "flag without value",
+			args:    []string{"-seq-url"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, rest, err := extractConfigFlags(tt.args, NewConfig())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got flags %v", flags)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !maps.Equal(flags, tt.wantFlags) {
+				t.Errorf("expected flags %v, got %v", tt.wantFlags, flags)
+			}
+			if !slices.Equal(rest, tt.wantRest) {
+				t.Errorf("expected rest %q, got %q", tt.wantRest, rest)
+			}
+		})
+	}
+}
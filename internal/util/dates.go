@@ -1,6 +1,10 @@
 package util
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // LastDayOfMonth returns the last day of the specified month and year.
 func LastDayOfMonth(t time.Time) time.Time {
@@ -37,3 +41,16 @@ func MonthString(t time.Time) string {
 
 	return m[t.Month()]
 }
+
+// ParseWeekday parses short or full English weekday name ("fri", "Friday", ...).
+func ParseWeekday(s string) (time.Weekday, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || name == full[:3] {
+			return d, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown weekday %q", s)
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// matches first number in price text, digit groups may be separated by regular,
+// non-breaking or thin spaces, decimal part is separated by comma or dot
+var priceRegexp = regexp.MustCompile(`(\d[\d\s\x{00a0}\x{202f}]*)(?:[.,](\d{1,2}))?`)
+
+// ParsePrice parses price in rubles from Avito price text
+// ("3 500 ₽", "от 2 000 ₽ за сутки", "1 234,50 ₽"), fractional part is rounded.
+func ParsePrice(str string) (int, error) {
+	match := priceRegexp.FindStringSubmatch(str)
+	if match == nil {
+		return 0, fmt.Errorf("no price found in %q", str)
+	}
+
+	integer, err := strconv.Atoi(Normalize(match[1]))
+	if err != nil {
+		return 0, fmt.Errorf("invalid price %q: %v", str, err)
+	}
+
+	if match[2] == "" {
+		return integer, nil
+	}
+
+	fraction, err := strconv.ParseFloat("0."+match[2], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid price %q: %v", str, err)
+	}
+
+	return int(math.Round(float64(integer) + fraction)), nil
+}
@@ -0,0 +1,42 @@
+package util
+
+import "testing"
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		name    string
+		str     string
+		want    int
+		wantErr bool
+	}{
+		{name: "plain number", str: "2500", want: 2500},
+		{name: "regular space groups", str: "3 500 ₽", want: 3500},
+		{name: "non-breaking space groups", str: "3\u00a0500\u00a0₽", want: 3500},
+		{name: "thin space groups", str: "12\u202f000\u00a0₽", want: 12000},
+		{name: "from price per night", str: "от 2 000 ₽ за сутки", want: 2000},
+		{name: "comma fraction rounded up", str: "1 234,50 ₽", want: 1235},
+		{name: "dot fraction rounded down", str: "999.4", want: 999},
+		{name: "first number only", str: "1 800 ₽ за сутки, 2 гостя", want: 1800},
+		{name: "no number", str: "Цена не указана", wantErr: true},
+		{name: "empty", str: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePrice(tt.str)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package internal
+
+import "fmt"
+
+// ValidateResult checks that counts of result are plausible, returns InvalidResultError
+// for result that must not get into time series and suspect InvalidResultError for doubtful one
+func ValidateResult(result *ParsingTaskResult) error {
+	if result.EstateFreeCount > result.EstateTotalCount {
+		return NewInvalidResultError(fmt.Sprintf("free count %d is greater than total count %d",
+			result.EstateFreeCount, result.EstateTotalCount), false)
+	}
+
+	if result.EstateTotalCount <= 0 && !result.IsNoResults {
+		return NewInvalidResultError("total count is zero, but page does not report there are no results", false)
+	}
+
+	// without date filter booked objects are counted as free, so the count does not describe the window
+	if !result.IsDateFilterApplied && !result.IsNoResults {
+		return NewInvalidResultError("date filter was not applied, free count includes booked objects", true)
+	}
+
+	// date filter hides booked objects, unchanged count means it most likely was not applied
+	if result.IsDateFilterApplied && result.EstateTotalCount > 0 && result.EstateFreeCount == result.EstateTotalCount {
+		return NewInvalidResultError(fmt.Sprintf("free count equals total count %d, though date filter was applied",
+			result.EstateTotalCount), true)
+	}
+
+	return nil
+}
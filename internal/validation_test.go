@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateResult(t *testing.T) {
+	tests := []struct {
+		name   string
+		result ParsingTaskResult
+		// suspect is checked only when error is expected
+		wantErr     bool
+		wantSuspect bool
+	}{
+		{
+			name:   "plausible counts",
+			result: ParsingTaskResult{EstateTotalCount: 120, EstateFreeCount: 30, IsDateFilterApplied: true},
+		},
+		{
+			name:   "no results",
+			result: ParsingTaskResult{IsNoResults: true},
+		},
+		{
+			name:    "free count above total",
+			result:  ParsingTaskResult{EstateTotalCount: 30, EstateFreeCount: 120, IsDateFilterApplied: true},
+			wantErr: true,
+		},
+		{
+			name:    "zero total without no results message",
+			result:  ParsingTaskResult{IsDateFilterApplied: true},
+			wantErr: true,
+		},
+		{
+			name:        "free count equals total",
+			result:      ParsingTaskResult{EstateTotalCount: 120, EstateFreeCount: 120, IsDateFilterApplied: true},
+			wantErr:     true,
+			wantSuspect: true,
+		},
+		{
+			name:        "date filter not applied",
+			result:      ParsingTaskResult{EstateTotalCount: 120, EstateFreeCount: 120},
+			wantErr:     true,
+			wantSuspect: true,
+		},
+		{
+			name:        "date filter not applied with lower free count",
+			result:      ParsingTaskResult{EstateTotalCount: 120, EstateFreeCount: 30},
+			wantErr:     true,
+			wantSuspect: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateResult(&tt.result)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var invalidErr *InvalidResultError
+			if !errors.As(err, &invalidErr) {
+				t.Fatalf("expected InvalidResultError, got %v", err)
+			}
+			if invalidErr.Suspect != tt.wantSuspect {
+				t.Errorf("expected suspect %v, got %v", tt.wantSuspect, invalidErr.Suspect)
+			}
+			if invalidErr.Reason == "" {
+				t.Error("expected error to have reason")
+			}
+		})
+	}
+}
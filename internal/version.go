@@ -0,0 +1,5 @@
+package internal
+
+// Version of parser recorded with every run, set at build time with
+// -ldflags "-X github.com/csr-ugra/avito-estate-parser/internal.Version=<version>"
+var Version = "dev"
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DateWindow is a check-in/check-out date pair the parser applies to the calendar filter
+type DateWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+func NewDateWindow(start time.Time, end time.Time) (DateWindow, error) {
+	if !end.After(start) {
+		return DateWindow{}, fmt.Errorf("date window end %s must be after start %s",
+			end.Format(time.DateOnly), start.Format(time.DateOnly))
+	}
+
+	return DateWindow{Start: start, End: end}, nil
+}
+
+// SweepOptions describes a forward horizon of date windows:
+// every check-in day in [From, From+Days) combined with every stay length,
+// optionally limited to check-ins on the given weekdays
+type SweepOptions struct {
+	From        time.Time
+	Days        int
+	StayLengths []int
+	Weekdays    []time.Weekday
+}
+
+func GenerateSweepWindows(opts SweepOptions) (windows []DateWindow, err error) {
+	if opts.Days <= 0 {
+		return nil, errors.New("sweep horizon must be at least one day")
+	}
+
+	if len(opts.StayLengths) == 0 {
+		return nil, errors.New("sweep requires at least one stay length")
+	}
+
+	for _, stay := range opts.StayLengths {
+		if stay <= 0 {
+			return nil, fmt.Errorf("stay length must be positive, got %d", stay)
+		}
+	}
+
+	windows = make([]DateWindow, 0, opts.Days*len(opts.StayLengths))
+	for day := 0; day < opts.Days; day++ {
+		checkIn := opts.From.AddDate(0, 0, day)
+		if len(opts.Weekdays) > 0 && !slices.Contains(opts.Weekdays, checkIn.Weekday()) {
+			continue
+		}
+
+		for _, stay := range opts.StayLengths {
+			windows = append(windows, DateWindow{
+				Start: checkIn,
+				End:   checkIn.AddDate(0, 0, stay),
+			})
+		}
+	}
+
+	if len(windows) == 0 {
+		return nil, errors.New("sweep options produced no date windows")
+	}
+
+	return windows, nil
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func date(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	d, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return d
+}
+
+func TestGenerateSweepWindows(t *testing.T) {
+	// 2026-10-16 is friday
+	const from = "2026-10-16"
+
+	tests := []struct {
+		name    string
+		opts    SweepOptions
+		want    [][2]string
+		wantErr bool
+	}{
+		{
+			name: "single day single stay",
+			opts: SweepOptions{Days: 1, StayLengths: []int{1}},
+			want: [][2]string{{"2026-10-16", "2026-10-17"}},
+		},
+		{
+			name: "every day with several stays",
+			opts: SweepOptions{Days: 2, StayLengths: []int{1, 7}},
+			want: [][2]string{
+				{"2026-10-16", "2026-10-17"},
+				{"2026-10-16", "2026-10-23"},
+				{"2026-10-17", "2026-10-18"},
+				{"2026-10-17", "2026-10-24"},
+			},
+		},
+		{
+			name: "weekend check-ins",
+			opts: SweepOptions{Days: 8, StayLengths: []int{2}, Weekdays: []time.Weekday{time.Friday, time.Saturday}},
+			want: [][2]string{
+				{"2026-10-16", "2026-10-18"},
+				{"2026-10-17", "2026-10-19"},
+				{"2026-10-23", "2026-10-25"},
+			},
+		},
+		{
+			name: "stay crossing month end",
+			opts: SweepOptions{Days: 1, StayLengths: []int{20}},
+			want: [][2]string{{"2026-10-16", "2026-11-05"}},
+		},
+		{
+			name:    "no days",
+			opts:    SweepOptions{Days: 0, StayLengths: []int{1}},
+			wantErr: true,
+		},
+		{
+			name:    "no stay lengths",
+			opts:    SweepOptions{Days: 3},
+			wantErr: true,
+		},
+		{
+			name:    "non positive stay length",
+			opts:    SweepOptions{Days: 3, StayLengths: []int{1, 0}},
+			wantErr: true,
+		},
+		{
+			name:    "no check-in on given weekdays",
+			opts:    SweepOptions{Days: 3, StayLengths: []int{1}, Weekdays: []time.Weekday{time.Tuesday}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.From = date(t, from)
+
+			windows, err := GenerateSweepWindows(tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %d windows", len(windows))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(windows) != len(tt.want) {
+				t.Fatalf("expected %d windows, got %d", len(tt.want), len(windows))
+			}
+			for i, w := range windows {
+				start, end := w.Start.Format(time.DateOnly), w.End.Format(time.DateOnly)
+				if start != tt.want[i][0] || end != tt.want[i][1] {
+					t.Errorf("window %d: expected %s - %s, got %s - %s", i, tt.want[i][0], tt.want[i][1], start, end)
+				}
+			}
+		})
+	}
+}
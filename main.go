@@ -4,14 +4,18 @@ import (
 	"context"
 	"fmt"
 	"github.com/csr-ugra/avito-estate-parser/cmd"
-	"github.com/csr-ugra/avito-estate-parser/internal/db"
 	"github.com/csr-ugra/avito-estate-parser/internal/log"
 	"github.com/csr-ugra/avito-estate-parser/internal/util"
 	"os"
 )
 
 func main() {
-	config := util.GetConfig()
+	config, args, err := util.LoadConfig(os.Args[1:])
+	if err != nil {
+		// logger is configured from config, so there is nothing to log with yet
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
 
 	log.InitLogger(config)
 
@@ -23,19 +27,13 @@ func main() {
 		}
 	}()
 
-	connection, err := db.GetConnection(config)
-	if err != nil {
-		// re-fetching logger to log with all fields appended during program run
-		logger := log.GetLogger()
-		logger.Fatalln(err)
-	}
-
 	ctx := context.Background()
 
-	err = cmd.Run(ctx, connection, config)
+	err = cmd.Run(ctx, config, args)
 	if err != nil {
+		// re-fetching logger to log with all fields appended during program run
 		logger := log.GetLogger()
-		fmt.Println(err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
 		logger.Fatal(err)
 	}
 